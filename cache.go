@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// cacheVersion is part of every cache key, bumped whenever the resolution changes so older entries are ignored.
+const cacheVersion = 3
+
+// cacheDir returns the folder the resolved animations are cached in.
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "clip-parse"), nil
+}
+
+// cacheKey identifies what the resolution of the animations depends on:
+// the sorted set of names along with the parts given by -trust-columns, the active profile and pattern
+// and the custom relation kinds.
+func cacheKey(animations []*Animation) string {
+	names := make([]string, 0, len(animations))
+	groups := make(map[string]Groups)
+	for _, animation := range animations {
+		if animation != nil {
+			names = append(names, animation.Name)
+			if animation.groups != nil {
+				groups[animation.Name] = animation.groups
+			}
+		}
+	}
+	sort.Strings(names)
+
+	kinds := make([]string, 0, len(relationPatterns))
+	for kind := range relationPatterns {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	profileJSON, _ := json.Marshal(profile)
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%s\n%s\n%q\n", cacheVersion, profileJSON, re, kinds)
+	for _, name := range names {
+		if given, ok := groups[name]; ok {
+			fmt.Fprintf(hash, "%s %q\n", name, []string(given))
+			continue
+		}
+		fmt.Fprintln(hash, name)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// cachedFetchAnimations returns the animations resolved by fetchAnimations, reading them from the cache when
+// a previous run resolved the same set, and caching them otherwise. A changed set has another key and is resolved again.
+// Failing to use the cache only costs the resolution, so cache errors are ignored.
+func cachedFetchAnimations(animations []*Animation) []*Animation {
+	dir, err := cacheDir()
+	if err != nil {
+		return fetchAnimations(animations)
+	}
+	path := filepath.Join(dir, cacheKey(animations)+".gob")
+
+	if cached, err := readGob(path); err == nil {
+		// The modification times, paths and weights aren't cached, they're the ones of this run
+		loaded := indexByName(animations)
+		for _, animation := range cached {
+			if original := loaded[animation.Name]; original != nil {
+				animation.modTime = original.modTime
+				animation.path = original.path
+				animation.weight, animation.weighted = original.weight, original.weighted
+			}
+		}
+		return cached
+	}
+
+	resolved := fetchAnimations(animations)
+	if err := os.MkdirAll(dir, 0o755); err == nil {
+		writeCacheFile(path, resolved)
+	}
+	return resolved
+}
+
+// writeCacheFile writes the resolved animations to path through a temporary file, so concurrent runs never read half a file.
+func writeCacheFile(path string, animations []*Animation) {
+	file, err := os.CreateTemp(filepath.Dir(path), "*.tmp")
+	if err != nil {
+		return
+	}
+	err = writeGob(file, animations)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return
+	}
+	os.Rename(file.Name(), path)
+}
+
+// clearCache removes every cached resolution.
+func clearCache() error {
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCachedFetchAnimations(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir, err := cacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := []string{"A_intro_01", "A_intro_02"}
+	after := []string{"A_intro_01", "A_intro_01-02", "A_intro_02"}
+	for _, names := range [][]string{before, before, after} {
+		got, err := json.Marshal(cachedFetchAnimations(animationsOf(names...)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := json.Marshal(fetchAnimations(animationsOf(names...)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("cached resolution of %q gave\n%s\nwant\n%s", names, got, want)
+		}
+		if _, err := os.Stat(filepath.Join(dir, cacheKey(animationsOf(names...))+".gob")); err != nil {
+			t.Errorf("resolving %q wasn't cached: %v", names, err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("cached %d resolutions, want one for each set", len(entries))
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	key := cacheKey(animationsOf("A_intro_01", "A_intro_02"))
+	if cacheKey(animationsOf("A_intro_02", "A_intro_01")) != key {
+		t.Error("the order of the names changed the cache key")
+	}
+	if cacheKey(animationsOf("A_intro_01", "A_intro_02", "A_intro_03")) == key {
+		t.Error("adding a name kept the cache key")
+	}
+
+	p := defaultProfile
+	p.CharWidth = 2
+	withProfile(t, p)
+	if cacheKey(animationsOf("A_intro_01", "A_intro_02")) == key {
+		t.Error("changing the profile kept the cache key")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "sort"
+
+// Completeness is the clip range of an action of a character and the clip numbers missing from it.
+type Completeness struct {
+	Char    string `json:"char"`
+	Action  string `json:"action"`
+	First   int    `json:"first"`
+	Last    int    `json:"last"`
+	Missing []int  `json:"missing"`
+}
+
+// checkCompleteness reports, for every character and action, the clip numbers missing between the profile base
+// and the highest clip number found, such as 02 for `A_intro_X_01` and `A_intro_X_03`.
+// Transitions don't count as clips of the sequence, and alternates and tagged clips count for their clip number.
+// The report is sorted by character, then action.
+func checkCompleteness(animations []*Animation) []Completeness {
+	type key struct{ char, action string }
+	numbers := make(map[key]map[int]bool)
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		parsed, err := ParseName(animation.Name)
+		if err != nil || parsed.TransitionTo != "" {
+			continue
+		}
+		k := key{char: parsed.Char, action: parsed.Action}
+		if numbers[k] == nil {
+			numbers[k] = make(map[int]bool)
+		}
+		numbers[k][atoi(parsed.Clip)] = true
+	}
+
+	report := make([]Completeness, 0, len(numbers))
+	for k, found := range numbers {
+		entry := Completeness{Char: k.char, Action: k.action, First: profile.Base, Missing: []int{}}
+		for number := range found {
+			if number > entry.Last {
+				entry.Last = number
+			}
+		}
+		for number := entry.First; number <= entry.Last; number++ {
+			if !found[number] {
+				entry.Missing = append(entry.Missing, number)
+			}
+		}
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Char != report[j].Char {
+			return report[i].Char < report[j].Char
+		}
+		return report[i].Action < report[j].Action
+	})
+	return report
+}
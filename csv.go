@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// writeCSVNodes writes a CSV table with one row per clip: its name, parsed parts and resolved counts.
+// The parts are empty for names that don't parse.
+func writeCSVNodes(w io.Writer, animations []*Animation) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"name", "action", "char", "clip", "alternate", "is_transition", "num_next", "num_alt"}); err != nil {
+		return err
+	}
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		parsed, _ := ParseName(animation.Name)
+		row := []string{
+			animation.Name,
+			parsed.Action,
+			parsed.Char,
+			parsed.Clip,
+			parsed.Alternate,
+			strconv.FormatBool(parsed.TransitionTo != ""),
+			strconv.Itoa(len(animation.NextAnimations)),
+			strconv.Itoa(len(animation.AlternateAnimations)),
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// readFromCSV reads the animations named by a CSV table, such as the output of -format csv-nodes.
+// A first row with a `name` column is a header, naming the column of the names; otherwise the names are the first column.
+// With -trust-columns, the header columns named after a group of the pattern, such as action, char and clip,
+// give the parts of each clip instead of matching its name again, see trustedGroups. A row they can't be trusted for
+// is matched by its name after a warning, or fails the read with -strict.
+func readFromCSV(path string) ([]*Animation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	in := csv.NewReader(file)
+	in.FieldsPerRecord = -1
+	records, err := in.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	nameColumn := 0
+	columns := make(map[Group]int)
+	if len(records) > 0 {
+		header := records[0]
+		for i, column := range header {
+			column = strings.TrimSpace(column)
+			if strings.EqualFold(column, "name") {
+				nameColumn = i
+				columns = make(map[Group]int)
+				for j, column := range header {
+					if group, ok := groupNamed(strings.TrimSpace(column)); ok && groupIndex[group] >= 0 {
+						columns[group] = j
+					}
+				}
+				records = records[1:]
+				break
+			}
+		}
+	}
+
+	var animations []*Animation
+	for _, record := range records {
+		if nameColumn >= len(record) {
+			continue
+		}
+		name := strings.TrimSpace(record[nameColumn])
+		if name == "" {
+			continue
+		}
+		animation := &Animation{Name: name}
+		if opts.trustColumns && len(columns) > 0 {
+			groups, err := trustedGroups(name, record, columns)
+			switch {
+			case err == nil:
+			case opts.strict:
+				return nil, fmt.Errorf("reading %s: %w", path, err)
+			case MatchGroups(name) != nil:
+				// Names that don't parse are warned about along with the others
+				fmt.Fprintf(os.Stderr, "warning: %v, matching the name instead of trusting its columns\n", err)
+			}
+			animation.groups = groups
+		}
+		animations = append(animations, animation)
+	}
+	return animations, nil
+}
+
+// trustedGroups returns the groups of name, after checking that the columns of record give the same parts.
+// Other clips find the clip by its name, so its columns are only trusted when the name matches the pattern with the
+// same parts: a name that doesn't parse or whose parts differ from the columns is an error, with nil groups.
+func trustedGroups(name string, record []string, columns map[Group]int) (Groups, error) {
+	groups, err := matchName(name)
+	if err != nil {
+		return nil, err
+	}
+	for group, column := range columns {
+		value := ""
+		if column < len(record) {
+			value = strings.TrimSpace(record[column])
+		}
+		if value != groups.group(group) {
+			return nil, &NameError{Name: name, Err: fmt.Errorf("the %s column is %q but the name has %q", group, value, groups.group(group))}
+		}
+	}
+	return groups, nil
+}
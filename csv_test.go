@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadFromCSV(t *testing.T) {
+	withOpts(t)
+
+	animations, err := readFromCSV(filepath.Join("testdata", "clips.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(animations); !equalNames(got, []string{"A_intro_01", "A_intro_01_B", "A_intro_02", "A_intro_03", "Intro Take 3"}) {
+		t.Fatalf("read %q", got)
+	}
+	set := indexByName(fetchAnimations(animations))
+	assertNext(t, set, "A_intro_01", "A_intro_02")
+	assertNext(t, set, "A_intro_02", "A_intro_03")
+	assertAlternates(t, set, "A_intro_01", "A_intro_01_B")
+	assertPrevious(t, set, "Intro Take 3", "")
+
+	// The rows whose names don't parse to their columns are matched by name, so relations go both ways
+	opts.trustColumns = true
+	animations, err = readFromCSV(filepath.Join("testdata", "clips.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, animation := range animations {
+		if trusted := animation.groups != nil; trusted != (animation.Name == "A_intro_01" || animation.Name == "A_intro_01_B" || animation.Name == "A_intro_02") {
+			t.Errorf("trusting the columns of %s = %v", animation.Name, trusted)
+		}
+	}
+	set = indexByName(fetchAnimations(animations))
+	assertNext(t, set, "A_intro_01", "A_intro_02")
+	assertPrevious(t, set, "A_intro_02", "A_intro_01")
+	assertAlternates(t, set, "A_intro_01_B", "A_intro_01")
+	assertNext(t, set, "A_intro_02", "A_intro_03")
+	assertPrevious(t, set, "A_intro_03", "A_intro_02")
+	assertNext(t, set, "A_intro_03")
+	assertPrevious(t, set, "Intro Take 3", "")
+
+	opts.strict = true
+	if _, err := readFromCSV(filepath.Join("testdata", "clips.csv")); err == nil || !strings.Contains(err.Error(), "A_intro_03: the clip column") {
+		t.Errorf("reading a row whose clip column differs from its name with -strict gave %v", err)
+	}
+}
+
+func TestReadFromCSVWithoutHeader(t *testing.T) {
+	withOpts(t)
+	opts.trustColumns = true
+	path := filepath.Join(t.TempDir(), "names.csv")
+	if err := os.WriteFile(path, []byte("A_intro_01,ignored\n\nA_intro_02\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	animations, err := readFromCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(animations); !equalNames(got, []string{"A_intro_01", "A_intro_02"}) {
+		t.Fatalf("read %q, want the names of the first column", got)
+	}
+	assertNext(t, indexByName(fetchAnimations(animations)), "A_intro_01", "A_intro_02")
+}
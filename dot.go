@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// writeDOT writes the transition graph in the Graphviz DOT language.
+// Next animations are `source -> target` edges and each pair of alternates is a single dashed two-way edge.
+// With -with-ids nodes and edges are identified by the animation IDs, and every node is labeled with its name.
+func writeDOT(w io.Writer, animations []*Animation) error {
+	return writeDOTGraph(w, animations, false)
+}
+
+// writeDOTClustered writes the DOT graph with the clips of every action boxed in a `cluster_<action>` subgraph.
+// Transitions into another action cross the cluster boundaries, and unparseable names stay outside of any cluster.
+func writeDOTClustered(w io.Writer, animations []*Animation) error {
+	return writeDOTGraph(w, animations, true)
+}
+
+func writeDOTGraph(w io.Writer, animations []*Animation, clustered bool) error {
+	if _, err := fmt.Fprintln(w, "digraph animations {"); err != nil {
+		return err
+	}
+
+	if clustered {
+		groups := GroupByAction(animations)
+		actions := make([]string, 0, len(groups))
+		for action := range groups {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+
+		for _, action := range actions {
+			indent := "\t"
+			if action != "" {
+				indent = "\t\t"
+				if _, err := fmt.Fprintf(w, "\tsubgraph %s {\n\t\tlabel=%s\n", dotID("cluster_"+action), dotID(action)); err != nil {
+					return err
+				}
+			}
+			for _, animation := range groups[action] {
+				if _, err := fmt.Fprintf(w, "%s%s\n", indent, dotNode(animation.Name)); err != nil {
+					return err
+				}
+			}
+			if action != "" {
+				if _, err := fmt.Fprintln(w, "\t}"); err != nil {
+					return err
+				}
+			}
+		}
+	} else {
+		for _, animation := range animations {
+			if animation == nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "\t%s\n", dotNode(animation.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, edge := range edges(animations, NextEdge, AlternateEdge) {
+		var err error
+		switch edge.Kind {
+		case NextEdge:
+			_, err = fmt.Fprintf(w, "\t%s -> %s\n", dotRef(edge.From), dotRef(edge.To))
+		case AlternateEdge:
+			if edge.From > edge.To {
+				// The other side lists the same pair
+				continue
+			}
+			_, err = fmt.Fprintf(w, "\t%s -> %s [dir=both style=dashed]\n", dotRef(edge.From), dotRef(edge.To))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// dotNode returns the statement declaring the node of the animation called name, labeled with the name with -with-ids.
+func dotNode(name string) string {
+	if opts.withIDs {
+		return dotRef(name) + " [label=" + dotID(name) + "]"
+	}
+	return dotID(name)
+}
+
+// dotRef returns the DOT identifier of the animation called name, its ID with -with-ids.
+func dotRef(name string) string {
+	if opts.withIDs {
+		return dotID(animationID(name))
+	}
+	return dotID(name)
+}
+
+// dotID quotes s as a DOT identifier. Names may contain spaces or any other character, only quotes and backslashes
+// need escaping, and a backslash is doubled so names don't turn into DOT escape sequences like `\n`.
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// writeEdgeList writes a `source target weight` line for every next animation, for shortest-path tools.
+// Edges into or out of a transition clip weigh -transition-weight and the others 1,
+// so routes prefer continuing a sequence over taking a transition.
+// Transitions are told apart by their names, so animations read with -input-index weigh the same as resolved ones.
+// Names with whitespace would read back as more than two names, so an edge between them fails instead.
+func writeEdgeList(w io.Writer, animations []*Animation) error {
+	byName := indexByName(animations)
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		for _, next := range animation.NextAnimations {
+			target := byName[next]
+			if target == nil {
+				target = &Animation{Name: next}
+			}
+			for _, name := range []string{animation.Name, next} {
+				if strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+					return fmt.Errorf("can't write the edge %s -> %s: %q has whitespace, which separates names in an edge list", animation.Name, next, name)
+				}
+			}
+			weight := 1.0
+			if animation.isTransition() || target.isTransition() {
+				weight = opts.transitionWeight
+			}
+			if _, err := fmt.Fprintf(w, "%s %s %s\n", animation.Name, next, strconv.FormatFloat(weight, 'g', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
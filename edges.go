@@ -0,0 +1,106 @@
+package main
+
+// EdgeKind is the relation an Edge stands for.
+type EdgeKind string
+
+const (
+	NextEdge      EdgeKind = "next"
+	AlternateEdge EdgeKind = "alternate"
+	PreviousEdge  EdgeKind = "previous"
+)
+
+// Reason is why a next animation was resolved.
+type Reason string
+
+const (
+	// ReasonSequential is the next clip of the sequence, `A_intro_01` -> `A_intro_02`.
+	ReasonSequential Reason = "sequential"
+	// ReasonTransition is a transition starting at the clip, `A_intro_01` -> `A_intro_01-02`.
+	ReasonTransition Reason = "transition"
+	// ReasonTransitionSameGroup is where a transition leads within its action, `A_intro_01-02` -> `A_intro_02`.
+	ReasonTransitionSameGroup Reason = "transition-same-group"
+	// ReasonTransitionCrossGroup is where a transition leads to another action, `A_intro_02-relax_01` -> `A_relax_01`.
+	ReasonTransitionCrossGroup Reason = "transition-cross-group"
+	// ReasonTransitionReverse is a bidirectional transition played backwards, `A_relax_01` -> `A_intro_01`
+	// by playing `A_intro_01<->relax_01` in reverse.
+	ReasonTransitionReverse Reason = "transition-reverse"
+	// ReasonAlternateAdvance is an alternate used as the next animation with -alts-as-next.
+	ReasonAlternateAdvance Reason = "alternate-advance"
+)
+
+// Successor is a next animation along with why it was resolved.
+type Successor struct {
+	Target string `json:"target"`
+	Reason Reason `json:"reason"`
+}
+
+// successors returns the next animations of the clip with their reasons.
+func (clip *Animation) successors() []Successor {
+	next := make([]Successor, 0, len(clip.NextAnimations))
+	for _, name := range clip.NextAnimations {
+		next = append(next, Successor{Target: name, Reason: clip.reasons[name]})
+	}
+	return next
+}
+
+// KindedNext are the next animations of a clip bucketed by what they were resolved from, output with -kinded-next.
+type KindedNext struct {
+	Sequential        []string `json:"sequential"`
+	Transitions       []string `json:"transitions"`
+	AlternateAdvances []string `json:"alternateAdvances"`
+}
+
+// kindedNext returns the next animations of the clip bucketed by their reasons.
+// The transitions hold the ones resolved from a transition of any kind, into it or out of it,
+// and next animations without a recorded reason, such as the ones read with -input-index, count as sequential.
+func (clip *Animation) kindedNext() KindedNext {
+	kinded := KindedNext{Sequential: []string{}, Transitions: []string{}, AlternateAdvances: []string{}}
+	for _, next := range clip.successors() {
+		switch next.Reason {
+		case ReasonTransition, ReasonTransitionSameGroup, ReasonTransitionCrossGroup, ReasonTransitionReverse:
+			kinded.Transitions = append(kinded.Transitions, next.Target)
+		case ReasonAlternateAdvance:
+			kinded.AlternateAdvances = append(kinded.AlternateAdvances, next.Target)
+		default:
+			kinded.Sequential = append(kinded.Sequential, next.Target)
+		}
+	}
+	return kinded
+}
+
+// Edge is a single relation from one animation to another.
+type Edge struct {
+	From string
+	To   string
+	Kind EdgeKind
+}
+
+// edges lists the relations of the given kinds, in the order of the animations.
+// Alternates are listed from both sides, since each alternate lists the other.
+func edges(animations []*Animation, kinds ...EdgeKind) []Edge {
+	want := make(map[EdgeKind]bool, len(kinds))
+	for _, kind := range kinds {
+		want[kind] = true
+	}
+
+	var all []Edge
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		if want[NextEdge] {
+			for _, next := range animation.NextAnimations {
+				all = append(all, Edge{From: animation.Name, To: next, Kind: NextEdge})
+			}
+		}
+		if want[AlternateEdge] {
+			for _, alternate := range animation.AlternateAnimations {
+				all = append(all, Edge{From: animation.Name, To: alternate, Kind: AlternateEdge})
+			}
+		}
+		if want[PreviousEdge] && animation.PreviousAnimation != "" {
+			all = append(all, Edge{From: animation.Name, To: animation.PreviousAnimation, Kind: PreviousEdge})
+		}
+	}
+	return all
+}
@@ -0,0 +1,40 @@
+package main
+
+import "errors"
+
+var (
+	// ErrUnparseableName is returned for names that don't match the naming pattern.
+	ErrUnparseableName = errors.New("name doesn't match the naming pattern")
+	// ErrEmptyComponent is returned for names matching the naming pattern with an empty action or clip number,
+	// which a custom pattern making them optional allows.
+	ErrEmptyComponent = errors.New("name matches the naming pattern with an empty required part")
+	// ErrNoAnimations is returned with -strict when the folders or manifest hold no animations.
+	ErrNoAnimations = errors.New("no animations found")
+	// ErrUnknownAnimation is returned when a name isn't part of the set.
+	ErrUnknownAnimation = errors.New("unknown animation")
+	// ErrDuplicateAnimation is returned when adding a name that's already part of the set.
+	ErrDuplicateAnimation = errors.New("duplicate animation")
+	// ErrNotTransition is returned when a transition animation was expected.
+	ErrNotTransition = errors.New("not a transition animation")
+	// ErrMissingTransitionTarget is returned for transition animations whose target doesn't exist.
+	ErrMissingTransitionTarget = errors.New("transition target doesn't exist")
+	// ErrNextCycle is returned for clips whose next animations lead back to them.
+	ErrNextCycle = errors.New("next animations lead back to the clip")
+	// ErrTransitionCycle is returned for transition animations whose next animations lead back to them.
+	ErrTransitionCycle = errors.New("transitions lead back to themselves")
+)
+
+// NameError records the animation name an error happened for.
+// The underlying error can be checked with errors.Is, e.g. errors.Is(err, ErrUnparseableName).
+type NameError struct {
+	Name string
+	Err  error
+}
+
+func (e *NameError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+func (e *NameError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// readExpected reads a hand-verified graph in the json output format from the file at path.
+func readExpected(path string) ([]*Animation, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var expected []*Animation
+	if err := json.Unmarshal(bytes, &expected); err != nil {
+		return nil, fmt.Errorf("reading expected animations from %s: %w", path, err)
+	}
+	return expected, nil
+}
+
+// compareExpected reports every difference between the resolved animations and the expected ones:
+// clips missing on either side, and clips whose next, alternate or previous animations differ.
+// Next and alternate animations are compared regardless of their order.
+func compareExpected(animations, expected []*Animation) []Issue {
+	var issues []Issue
+	mismatch := func(name, format string, args ...any) {
+		issues = append(issues, Issue{Check: "expect", Name: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	resolved := indexByName(animations)
+	wanted := indexByName(expected)
+	for _, want := range expected {
+		if want == nil {
+			continue
+		}
+		got := resolved[want.Name]
+		if got == nil {
+			mismatch(want.Name, "expected clip wasn't found")
+			continue
+		}
+		if !sameNames(got.NextAnimations, want.NextAnimations) {
+			mismatch(want.Name, "next animations are [%s], expected [%s]", strings.Join(got.NextAnimations, ", "), strings.Join(want.NextAnimations, ", "))
+		}
+		if !sameNames(got.AlternateAnimations, want.AlternateAnimations) {
+			mismatch(want.Name, "alternate animations are [%s], expected [%s]", strings.Join(got.AlternateAnimations, ", "), strings.Join(want.AlternateAnimations, ", "))
+		}
+		if got.PreviousAnimation != want.PreviousAnimation {
+			mismatch(want.Name, "previous animation is %q, expected %q", got.PreviousAnimation, want.PreviousAnimation)
+		}
+	}
+	for _, got := range animations {
+		if got != nil && wanted[got.Name] == nil {
+			mismatch(got.Name, "clip isn't expected")
+		}
+	}
+	return issues
+}
+
+// sameNames reports whether a and b hold the same names in any order.
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// trace receives every lookup made while resolving when set, see explain.
+var trace io.Writer
+
+// traceLookup writes the candidates an expression matched and the one chosen, if any.
+func traceLookup(expression string, candidates []*Animation, chosen *Animation) {
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		names = append(names, candidate.Name)
+	}
+	matched := "nothing"
+	if len(names) > 0 {
+		matched = "[" + strings.Join(names, ", ") + "]"
+	}
+
+	if chosen != nil {
+		fmt.Fprintf(trace, "  %s matched %s, chose %s\n", expression, matched, chosen.Name)
+		return
+	}
+	fmt.Fprintf(trace, "  %s matched %s\n", expression, matched)
+}
+
+// explain writes how name parses and which candidates were considered and chosen
+// for its next, previous and alternate animations among animations.
+func explain(w io.Writer, name string, animations []*Animation) {
+	trace = w
+	defer func() { trace = nil }()
+
+	fmt.Fprintln(w, name)
+	parsed, err := ParseName(name)
+	if err != nil {
+		fmt.Fprintf(w, "  %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "  action=%q char=%q clip=%q alternate=%q transitionTo=%q nextName=%q nextChar=%q nextClip=%q\n",
+		parsed.Action, parsed.Char, parsed.Clip, parsed.Alternate, parsed.TransitionTo, parsed.NextName, parsed.NextChar, parsed.NextClip)
+
+	clip := &Animation{Name: name}
+	index := indexSet(animations)
+
+	fmt.Fprintln(w, "next:")
+	clip.getNextAnimation(index)
+	if len(clip.NextAnimations) == 0 {
+		fmt.Fprintln(w, "  -> none")
+	}
+	for _, next := range clip.successors() {
+		fmt.Fprintf(w, "  -> %s (%s)\n", next.Target, next.Reason)
+	}
+
+	fmt.Fprintln(w, "previous:")
+	clip.getPreviousAnimation(index)
+	if clip.PreviousAnimation == "" {
+		fmt.Fprintln(w, "  -> none")
+	} else {
+		fmt.Fprintf(w, "  -> %s\n", clip.PreviousAnimation)
+	}
+
+	fmt.Fprintln(w, "alternates:")
+	clip.getAlternateAnimation(index)
+	if len(clip.AlternateAnimations) == 0 {
+		fmt.Fprintln(w, "  -> none")
+	}
+	for _, alternate := range clip.AlternateAnimations {
+		fmt.Fprintf(w, "  -> %s\n", alternate)
+	}
+}
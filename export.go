@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formats are the output formats selectable with -format.
+var formats = map[string]func(w io.Writer, animations []*Animation) error{
+	"json":     writeJSON,
+	"d2":       writeD2,
+	"dot":      writeDOT,
+	"edgelist": writeEdgeList,
+	"gob":      writeGob,
+	"html":     writeHTML,
+	"sql":      writeSQL,
+	"table":    writeTable,
+	"tgf":      writeTGF,
+
+	"csv-nodes":     writeCSVNodes,
+	"dot-clustered": writeDOTClustered,
+	"json-stream":   writeJSONStream,
+	"ndjson-edges":  writeNDJSONEdges,
+	"yaml-anchors":  writeYAMLAnchors,
+}
+
+// formatNames returns the names of the output formats, sorted.
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeJSON(w io.Writer, animations []*Animation) error {
+	bytes, err := json.Marshal(jsonValues(animations))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return err
+}
+
+// jsonValues returns what to marshal for the animations, see jsonValue.
+// An empty set is always an empty array rather than null.
+func jsonValues(animations []*Animation) any {
+	if animations == nil {
+		animations = []*Animation{}
+	}
+	if !opts.reasons && !opts.kindedNext && !opts.weights {
+		return animations
+	}
+
+	byName := indexByName(animations)
+	values := make([]any, len(animations))
+	for i, animation := range animations {
+		if animation != nil {
+			values[i] = jsonValue(animation, byName)
+		}
+	}
+	return values
+}
+
+// jsonValue returns what to marshal for the animation, the weights of its alternates being looked up in byName.
+// With -reasons the next animations are Successor objects instead of bare names, with -kinded-next a KindedNext object,
+// and with -weights the alternate animations are WeightedAlternate objects.
+func jsonValue(animation *Animation, byName map[string]*Animation) any {
+	if !opts.reasons && !opts.kindedNext && !opts.weights {
+		return animation
+	}
+
+	// The outer fields take precedence over the embedded ones
+	type plain Animation
+	type detailed struct {
+		plain
+		NextAnimations      any `json:"NextAnimations"`
+		AlternateAnimations any `json:"AlternateAnimations"`
+	}
+	value := detailed{plain: plain(*animation), NextAnimations: animation.NextAnimations, AlternateAnimations: animation.AlternateAnimations}
+	if opts.reasons {
+		value.NextAnimations = animation.successors()
+	}
+	if opts.kindedNext {
+		value.NextAnimations = animation.kindedNext()
+	}
+	if opts.weights {
+		value.AlternateAnimations = animation.weightedAlternates(byName)
+	}
+	return value
+}
+
+// writeJSONStream writes one JSON object per line for every animation, flushing each line as soon as it's encoded,
+// so a slow reader such as an uploader behind a pipe holds back the output instead of it piling up in memory.
+func writeJSONStream(w io.Writer, animations []*Animation) error {
+	var byName map[string]*Animation
+	if opts.weights {
+		byName = indexByName(animations)
+	}
+
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		if err := encoder.Encode(jsonValue(animation, byName)); err != nil {
+			return err
+		}
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeD2 writes the transition graph in the D2 diagram language.
+// Every clip is declared once, next animations are `source -> target` connections
+// and each pair of alternates is a single dashed `a <-> b` connection.
+func writeD2(w io.Writer, animations []*Animation) error {
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		declaration := d2Node(animation.Name)
+		if opts.withIDs {
+			declaration += ": " + strconv.Quote(animation.Name)
+		}
+		if _, err := fmt.Fprintln(w, declaration); err != nil {
+			return err
+		}
+	}
+
+	for _, edge := range edges(animations, NextEdge, AlternateEdge) {
+		var err error
+		switch edge.Kind {
+		case NextEdge:
+			_, err = fmt.Fprintf(w, "%s -> %s\n", d2Node(edge.From), d2Node(edge.To))
+		case AlternateEdge:
+			if edge.From > edge.To {
+				// The other side lists the same pair
+				continue
+			}
+			_, err = fmt.Fprintf(w, "%s <-> %s: {style.stroke-dash: 3}\n", d2Node(edge.From), d2Node(edge.To))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// d2Node returns the D2 identifier of the animation called name, its ID with -with-ids.
+func d2Node(name string) string {
+	if opts.withIDs {
+		return animationID(name)
+	}
+	return strconv.Quote(name)
+}
+
+// writeSQL writes INSERT statements for the animations and for every next, alternate and previous relation,
+// into the tables named by -sql-animations-table and -sql-edges-table.
+func writeSQL(w io.Writer, animations []*Animation) error {
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "INSERT INTO %s(name) VALUES (%s);\n", opts.sqlAnimationsTable, sqlString(animation.Name)); err != nil {
+			return err
+		}
+	}
+
+	for _, edge := range edges(animations, NextEdge, AlternateEdge, PreviousEdge) {
+		if _, err := fmt.Fprintf(w, "INSERT INTO %s(source, target, kind) VALUES (%s, %s, %s);\n",
+			opts.sqlEdgesTable, sqlString(edge.From), sqlString(edge.To), sqlString(string(edge.Kind))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlString quotes s as an SQL string literal.
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// writeNDJSONEdges writes one JSON object per line for every next, alternate and previous relation,
+// such as {"from":"A_intro_01","to":"A_intro_02","type":"next"}, for bulk edge importers of graph databases.
+// Edges are written as they're enumerated, one animation at a time.
+func writeNDJSONEdges(w io.Writer, animations []*Animation) error {
+	type line struct {
+		From string   `json:"from"`
+		To   string   `json:"to"`
+		Type EdgeKind `json:"type"`
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, animation := range animations {
+		for _, edge := range edges([]*Animation{animation}, NextEdge, AlternateEdge, PreviousEdge) {
+			if err := encoder.Encode(line{From: edge.From, To: edge.To, Type: edge.Kind}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
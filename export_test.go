@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONEmpty(t *testing.T) {
+	withOpts(t)
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.txt")
+	if err := os.WriteFile(manifest, []byte("# nothing yet\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fromFolder, err := readFromFolders([]string{filepath.Join(dir, "empty")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromManifest, err := readFromManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for source, animations := range map[string][]*Animation{"folder": fromFolder, "manifest": fromManifest} {
+		resolved := fetchAnimations(animations)
+
+		var out bytes.Buffer
+		if err := writeJSON(&out, resolved); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(out.String()); got != "[]" {
+			t.Errorf("empty %s gave %s, want []", source, got)
+		}
+
+		issues := validate(resolved)
+		if len(issues) != 1 || issues[0].Check != "empty" {
+			t.Errorf("validating an empty %s gave %v, want the no animations found issue", source, issues)
+		}
+
+		opts.strict = false
+		if err := requireAnimations(animations); err != nil {
+			t.Errorf("an empty %s without -strict: %v", source, err)
+		}
+		opts.strict = true
+		if err := requireAnimations(animations); !errors.Is(err, ErrNoAnimations) {
+			t.Errorf("an empty %s with -strict gave %v, want ErrNoAnimations", source, err)
+		}
+	}
+}
+
+func TestNamesWithSpaces(t *testing.T) {
+	withOpts(t)
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.txt")
+	if err := os.WriteFile(manifest, []byte("  A intro 01  \nA_b \"q\"\nA_intro_01\nA_intro_02\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	animations, err := readFromManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := fetchAnimations(animations)
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, resolved); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*Animation
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	set := indexByName(decoded)
+	assertNext(t, set, "A intro 01")
+	assertNext(t, set, `A_b "q"`)
+	assertNext(t, set, "A_intro_01", "A_intro_02")
+
+	out.Reset()
+	if err := writeDOT(&out, resolved); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"A intro 01"`, `"A_b \"q\""`, `"A_intro_01" -> "A_intro_02"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("DOT output lacks %s:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteDOTWithIDs(t *testing.T) {
+	withOpts(t)
+	opts.withIDs = true
+	resolved := fetchAnimations(animationsOf("A_intro_01", "A_intro_01_B", "A_intro_02"))
+	first, alternate, second := dotID(animationID("A_intro_01")), dotID(animationID("A_intro_01_B")), dotID(animationID("A_intro_02"))
+
+	for format, write := range map[string]func(io.Writer, []*Animation) error{"dot": writeDOT, "dot-clustered": writeDOTClustered} {
+		var out bytes.Buffer
+		if err := write(&out, resolved); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			first + ` [label="A_intro_01"]`,
+			first + " -> " + second + "\n",
+			first + " -> " + alternate + " [dir=both style=dashed]",
+		} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s output lacks %s:\n%s", format, want, out.String())
+			}
+		}
+		if strings.Contains(out.String(), `"A_intro_01" ->`) {
+			t.Errorf("%s output has edges between names rather than IDs:\n%s", format, out.String())
+		}
+	}
+}
+
+func TestWriteEdgeListWeights(t *testing.T) {
+	withOpts(t)
+	opts.transitionWeight = 5
+	resolved := fetchAnimations(animationsOf("A_intro_01", "A_intro_01-02", "A_intro_02", "A_intro_03"))
+
+	// Animations read with -input-index have no reasons
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := writeIndexFile(path, resolved); err != nil {
+		t.Fatal(err)
+	}
+	index, err := readIndexFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "A_intro_01 A_intro_01-02 5\nA_intro_01-02 A_intro_02 5\nA_intro_02 A_intro_03 1\n"
+	for source, animations := range map[string][]*Animation{"resolved": resolved, "index": index} {
+		var out bytes.Buffer
+		if err := writeEdgeList(&out, animations); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != want {
+			t.Errorf("edge list of the %s animations:\n%s\nwant\n%s", source, out.String(), want)
+		}
+	}
+}
+
+func TestWriteEdgeListSpaces(t *testing.T) {
+	withOpts(t)
+	animations := []*Animation{
+		{Name: "Intro Take 3"},
+		{Name: "A_intro_01", NextAnimations: []string{"A_intro_02"}},
+		{Name: "A_intro_02"},
+	}
+	var out bytes.Buffer
+	if err := writeEdgeList(&out, animations); err != nil {
+		t.Fatalf("a name with spaces and no edges failed the edge list: %v", err)
+	}
+
+	animations[2].NextAnimations = []string{"Intro Take 3"}
+	if err := writeEdgeList(&out, animations); err == nil || !strings.Contains(err.Error(), `"Intro Take 3"`) {
+		t.Errorf("an edge into Intro Take 3 gave %v, want an error naming it", err)
+	}
+}
+
+// recordingWriter records every write it's given, standing in for a slow uploader reading the output behind a pipe.
+type recordingWriter struct {
+	writes []string
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestWriteJSONStream(t *testing.T) {
+	withOpts(t)
+	resolved := fetchAnimations(animationsOf(benchmarkNames(5)...))
+
+	w := &recordingWriter{}
+	if err := writeJSONStream(w, resolved); err != nil {
+		t.Fatal(err)
+	}
+	// Every record reaches the writer on its own, so no more than one is ever held in memory
+	if len(w.writes) != len(resolved) {
+		t.Fatalf("wrote %d times for %d animations", len(w.writes), len(resolved))
+	}
+	for i, write := range w.writes {
+		var decoded Animation
+		if strings.Count(write, "\n") != 1 || json.Unmarshal([]byte(write), &decoded) != nil {
+			t.Fatalf("write %d isn't one JSON line: %q", i, write)
+		}
+		if decoded.Name != resolved[i].Name {
+			t.Errorf("write %d is %s, want %s", i, decoded.Name, resolved[i].Name)
+		}
+	}
+}
+
+func TestWriteYAMLAnchorsFields(t *testing.T) {
+	withOpts(t)
+	resolved := fetchAnimations(animationsOf("A_intro_01", "A_intro_01_A", "A_intro_02"))
+	set := indexByName(resolved)
+	depth := 1
+	set["A_intro_02"].Depth = &depth
+	set["A_intro_02"].Path = "animations/A_intro_02.anim"
+	set["A_intro_01"].Members = []string{"A_intro_01", "A_intro_01_A"}
+	set["A_intro_01"].Relations = map[string][]string{"mirror": {"A_intro_02"}}
+
+	var out bytes.Buffer
+	if err := writeYAMLAnchors(&out, resolved); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  NextAnimations: &list1\n    - A_intro_02\n",
+		"  NextAnimations: *list1\n",
+		"  Members:\n    - A_intro_01\n    - A_intro_01_A\n",
+		"  Relations:\n    mirror:\n      - A_intro_02\n",
+		"  Depth: 1\n",
+		"  Path: \"animations/A_intro_02.anim\"\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("YAML output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// setClipIndices sets the ClipIndex of every animation from its parsed clip number.
+// Animations whose name can't be parsed get -1.
+func setClipIndices(animations []*Animation) {
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		index := -1
+		if result := MatchGroups(animation.Name); result != nil {
+			index = atoi(result.Clip())
+		}
+		animation.ClipIndex = &index
+	}
+}
+
+// setDepths sets the Depth of every animation with a breadth-first walk of the next animations from the Roots.
+// Roots have depth 0, alternates share the depth of the member of their family the walk reaches
+// and the clips no root leads to get -1.
+func setDepths(animations []*Animation) {
+	byName := indexByName(animations)
+	depths := make(map[string]int)
+	var queue []string
+	for _, root := range Roots(animations) {
+		depths[root] = 0
+		queue = append(queue, root)
+	}
+	for len(queue) > 0 {
+		clip := byName[queue[0]]
+		queue = queue[1:]
+		for _, next := range clip.NextAnimations {
+			if _, seen := depths[next]; seen || byName[next] == nil {
+				continue
+			}
+			depths[next] = depths[clip.Name] + 1
+			queue = append(queue, next)
+		}
+	}
+
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		depth, ok := depths[animation.Name]
+		if !ok {
+			depth = -1
+			for _, alternate := range animation.AlternateAnimations {
+				if reached, ok := depths[alternate]; ok && (depth < 0 || reached < depth) {
+					depth = reached
+				}
+			}
+		}
+		animation.Depth = &depth
+	}
+}
+
+// setPaths sets the Path of every animation read from a folder.
+func setPaths(animations []*Animation) {
+	for _, animation := range animations {
+		if animation != nil {
+			animation.Path = animation.path
+		}
+	}
+}
+
+// pathsAsRefs returns copies of the animations whose next, alternate and previous animations are the files
+// they were read from, looked up in all, keeping the names of the animations that weren't read from a folder.
+func pathsAsRefs(animations, all []*Animation) []*Animation {
+	byName := indexByName(all)
+	ref := func(name string) string {
+		if target := byName[name]; target != nil && target.path != "" {
+			return target.path
+		}
+		return name
+	}
+	refs := func(names []string) []string {
+		if names == nil {
+			return nil
+		}
+		paths := make([]string, len(names))
+		for i, name := range names {
+			paths[i] = ref(name)
+		}
+		return paths
+	}
+
+	rewritten := make([]*Animation, len(animations))
+	for i, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		clip := *animation
+		clip.NextAnimations = refs(animation.NextAnimations)
+		clip.reasons = make(map[string]Reason, len(animation.reasons))
+		for name, reason := range animation.reasons {
+			clip.reasons[ref(name)] = reason
+		}
+		clip.AlternateAnimations = refs(animation.AlternateAnimations)
+		if animation.PreviousAnimation != "" {
+			clip.PreviousAnimation = ref(animation.PreviousAnimation)
+		}
+		rewritten[i] = &clip
+	}
+	return rewritten
+}
+
+// animationID returns a short ID that only depends on the name: the first 8 hex characters of its SHA-256.
+func animationID(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:4])
+}
+
+// setIDs sets the ID of every animation.
+func setIDs(animations []*Animation) {
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		animation.ID = animationID(animation.Name)
+	}
+}
+
+// setUnparsed marks the animations whose name doesn't match the naming pattern,
+// telling them apart from parsed clips that have no relations.
+func setUnparsed(animations []*Animation) {
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		_, err := ParseName(animation.Name)
+		animation.Unparsed = err != nil
+	}
+}
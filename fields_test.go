@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestPathsAsRefs(t *testing.T) {
+	animations := animationsOf("A_intro_01", "A_intro_01_B", "A_intro_02", "A_intro_03")
+	animations[0].path = "animations/A_intro_01.anim"
+	animations[2].path = "animations/A_intro_02.fbx"
+	resolved := fetchAnimations(animations)
+
+	set := indexByName(pathsAsRefs(resolved, resolved))
+	assertNext(t, set, "A_intro_01", "animations/A_intro_02.fbx")
+	assertNext(t, set, "A_intro_02", "A_intro_03")
+	assertAlternates(t, set, "A_intro_01_B", "animations/A_intro_01.anim")
+	assertAlternates(t, set, "A_intro_01", "A_intro_01_B")
+	assertPrevious(t, set, "A_intro_02", "animations/A_intro_01.anim")
+	assertPrevious(t, set, "A_intro_03", "animations/A_intro_02.fbx")
+	if reason := set["A_intro_01"].reasons["animations/A_intro_02.fbx"]; reason != ReasonSequential {
+		t.Errorf("reason of the rewritten next animation = %q, want %q", reason, ReasonSequential)
+	}
+
+	// The resolved animations are left as they were
+	assertNext(t, indexByName(resolved), "A_intro_01", "A_intro_02")
+}
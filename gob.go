@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+// gobVersion is the version of the gob format written by -format gob.
+// It's bumped whenever a change to gobAnimation can't be decoded by older versions.
+//
+// A gob file is a gobHeader followed by a []gobAnimation, in the order of the animations.
+// Fields added to the records are ignored by older readers and left empty when reading older files,
+// so adding fields doesn't need a new version while removing or retyping them does.
+const gobVersion = 1
+
+type gobHeader struct {
+	Version int
+}
+
+// gobAnimation is the record of an animation in a gob file, including the reasons the JSON output only shows with -reasons.
+type gobAnimation struct {
+	Animation
+	Reasons  map[string]Reason
+	SelfLoop bool
+}
+
+// writeGob writes the resolved animations in the gob binary format, which -input reads back.
+func writeGob(w io.Writer, animations []*Animation) error {
+	records := make([]gobAnimation, 0, len(animations))
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		records = append(records, gobAnimation{Animation: *animation, Reasons: animation.reasons, SelfLoop: animation.selfLoop})
+	}
+
+	encoder := gob.NewEncoder(w)
+	if err := encoder.Encode(gobHeader{Version: gobVersion}); err != nil {
+		return err
+	}
+	return encoder.Encode(records)
+}
+
+// readGob reads the resolved animations written by -format gob to the file at path.
+func readGob(path string) ([]*Animation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := gob.NewDecoder(file)
+	var header gobHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if header.Version != gobVersion {
+		return nil, fmt.Errorf("reading %s: unsupported gob version %d, expected %d", path, header.Version, gobVersion)
+	}
+
+	var records []gobAnimation
+	if err := decoder.Decode(&records); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	animations := make([]*Animation, len(records))
+	for i, record := range records {
+		animation := record.Animation
+		animation.reasons = record.Reasons
+		animation.selfLoop = record.SelfLoop
+		animations[i] = &animation
+	}
+	return animations, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+// Graph is the adjacency of the next, alternate and previous relations of a set of animations, in both directions.
+// It's derived from the fields of the animations it was built from and must be built again when they change.
+type Graph struct {
+	out map[string][]Edge
+	in  map[string][]Edge
+}
+
+// NewGraph builds the graph of the relations of the animations in a single pass.
+func NewGraph(animations []*Animation) *Graph {
+	graph := &Graph{
+		out: make(map[string][]Edge),
+		in:  make(map[string][]Edge),
+	}
+	for _, edge := range edges(animations, NextEdge, AlternateEdge, PreviousEdge) {
+		graph.out[edge.From] = append(graph.out[edge.From], edge)
+		graph.in[edge.To] = append(graph.in[edge.To], edge)
+	}
+	return graph
+}
+
+// Out returns the relations starting at the animation called name, in the order next, alternate and previous.
+func (graph *Graph) Out(name string) []Edge {
+	return graph.out[name]
+}
+
+// In returns the relations ending at the animation called name, in the order of the animations they start at.
+// The next edges ending at a clip are the ones playing into it, such as `A_intro_01` -> `A_intro_02`.
+func (graph *Graph) In(name string) []Edge {
+	return graph.in[name]
+}
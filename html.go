@@ -0,0 +1,45 @@
+package main
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlReport is a self-contained page listing every animation, with its relations linking to the referenced anchors.
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Animations</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+section { border-bottom: 1px solid #ddd; padding: 0.5em 0; }
+section:target { background: #fff8d6; }
+dt { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Animations</h1>
+<p>{{len .}} animations</p>
+{{range .}}<section id="{{.Name}}">
+<h2>{{.Name}}</h2>
+<dl>
+<dt>Next</dt><dd>{{template "links" .NextAnimations}}</dd>
+<dt>Alternates</dt><dd>{{template "links" .AlternateAnimations}}</dd>
+<dt>Previous</dt><dd>{{with .PreviousAnimation}}<a href="#{{.}}">{{.}}</a>{{else}}none{{end}}</dd>
+</dl>
+</section>
+{{end}}</body>
+</html>
+{{define "links"}}{{range $i, $name := .}}{{if $i}}, {{end}}<a href="#{{$name}}">{{$name}}</a>{{else}}none{{end}}{{end}}`))
+
+// writeHTML writes a standalone HTML report of the animations, linking each relation to the clip it references.
+func writeHTML(w io.Writer, animations []*Animation) error {
+	var present []*Animation
+	for _, animation := range animations {
+		if animation != nil {
+			present = append(present, animation)
+		}
+	}
+	return htmlReport.Execute(w, present)
+}
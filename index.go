@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// Index looks up the animations names are resolved against.
+// AnimationSet is the in-memory implementation. Others, such as one backed by a database,
+// only need to answer these two lookups to resolve sets that don't fit in memory.
+type Index interface {
+	// ByName returns the animation called name, or nil if there is none.
+	ByName(name string) *Animation
+	// ByPrefix returns the animations whose name starts with prefix, sorted by name.
+	ByPrefix(prefix string) []*Animation
+}
+
+// ByName returns the animation called name, or nil if there is none.
+// Unlike Get it doesn't resolve the animation in a lazy set, since it's used while resolving.
+func (set *AnimationSet) ByName(name string) *Animation {
+	return set.byName[name]
+}
+
+// ByPrefix returns the animations whose name starts with prefix, sorted by name.
+// It relies on set.Animations being sorted by name, as returned by fetchAnimations.
+func (set *AnimationSet) ByPrefix(prefix string) []*Animation {
+	start := sort.Search(len(set.Animations), func(i int) bool {
+		return set.Animations[i] == nil || set.Animations[i].Name >= prefix
+	})
+	end := start
+	for end < len(set.Animations) && set.Animations[end] != nil && strings.HasPrefix(set.Animations[end].Name, prefix) {
+		end++
+	}
+	return set.Animations[start:end]
+}
+
+// candidates returns the animations of the index that can match reg, narrowed down by the literal text every match starts with.
+func candidates(reg *regexp.Regexp, index Index) []*Animation {
+	return index.ByPrefix(literalPrefix(reg))
+}
+
+// literalPrefix returns the literal text every match of the anchored reg starts with, such as `A_intro_02` for `^A_intro_02_?A?$`.
+// Unlike reg.LiteralPrefix, it also handles expressions that don't compile to a one-pass program.
+func literalPrefix(reg *regexp.Regexp) string {
+	parsed, err := syntax.Parse(reg.String(), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	parsed = parsed.Simplify()
+
+	parts := []*syntax.Regexp{parsed}
+	if parsed.Op == syntax.OpConcat {
+		parts = parsed.Sub
+	}
+	if len(parts) == 0 || parts[0].Op != syntax.OpBeginText {
+		return ""
+	}
+
+	var prefix strings.Builder
+	for _, part := range parts[1:] {
+		if part.Op != syntax.OpLiteral || part.Flags&syntax.FoldCase != 0 {
+			break
+		}
+		prefix.WriteString(string(part.Rune))
+	}
+	return prefix.String()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// indexFileVersion is the version of the index files written by -export-index.
+const indexFileVersion = 1
+
+// indexFile is the JSON document written by -export-index and read by -input-index:
+// the resolved animations keyed by name, with the metadata of the run that generated them.
+type indexFile struct {
+	Version    int                   `json:"version"`
+	Generated  time.Time             `json:"generated"`
+	Count      int                   `json:"count"`
+	Animations map[string]*Animation `json:"animations"`
+}
+
+// writeIndexFile writes the resolved animations to the index file at path.
+func writeIndexFile(path string, animations []*Animation) error {
+	index := indexFile{
+		Version:    indexFileVersion,
+		Generated:  time.Now().UTC(),
+		Animations: indexByName(animations),
+	}
+	index.Count = len(index.Animations)
+
+	bytes, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bytes, 0o644)
+}
+
+// readIndexFile reads the animations of the index file at path, sorted by name.
+// The reasons of the next animations aren't part of the file, so -reasons shows them empty.
+func readIndexFile(path string) ([]*Animation, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var index indexFile
+	if err := json.Unmarshal(bytes, &index); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if index.Version != indexFileVersion {
+		return nil, fmt.Errorf("reading %s: unsupported index version %d, expected %d", path, index.Version, indexFileVersion)
+	}
+
+	animations := make([]*Animation, 0, len(index.Animations))
+	for name, animation := range index.Animations {
+		if animation == nil {
+			continue
+		}
+		animation.Name = name
+		animations = append(animations, animation)
+	}
+	sort.Slice(animations, func(i, j int) bool { return animations[i].Name < animations[j].Name })
+	return animations, nil
+}
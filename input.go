@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultFolder is the folder walked when no folder or manifest is given.
+const defaultFolder = "animations"
+
+// loadAnimations loads the animations named by -manifest, or found in the folders given as arguments.
+// With -input or -input-index, it loads the animations already resolved by an earlier run instead,
+// or the names of a CSV table when the -input file ends in `.csv`.
+// Animations sharing a name are dropped by dropDuplicates.
+func loadAnimations() ([]*Animation, error) {
+	animations, err := readAnimations()
+	if err != nil {
+		return nil, err
+	}
+	return dropDuplicates(animations)
+}
+
+// readAnimations reads the animations from the source selected by the flags, see loadAnimations.
+func readAnimations() ([]*Animation, error) {
+	if opts.input != "" || opts.inputIndex != "" {
+		if opts.input != "" && opts.inputIndex != "" || opts.manifest != "" || flag.NArg() > 0 {
+			return nil, errors.New("-input and -input-index can't be combined with each other, -manifest or folder arguments")
+		}
+		if opts.inputIndex != "" {
+			return readIndexFile(opts.inputIndex)
+		}
+		if isCSV(opts.input) {
+			return readFromCSV(opts.input)
+		}
+		return readGob(opts.input)
+	}
+	if opts.manifest != "" {
+		if flag.NArg() > 0 {
+			return nil, errors.New("-manifest can't be combined with folder arguments")
+		}
+		return readFromManifest(opts.manifest)
+	}
+
+	folders := flag.Args()
+	if len(folders) == 0 {
+		folders = []string{defaultFolder}
+	}
+	return readFromFolders(folders)
+}
+
+// inputResolved reports whether the animations are read already resolved, with -input-index or a gob -input.
+func inputResolved() bool {
+	return opts.inputIndex != "" || opts.input != "" && !isCSV(opts.input)
+}
+
+// requireAnimations fails with ErrNoAnimations under -strict when no animations were loaded,
+// rather than writing an empty result that looks like a successful run.
+func requireAnimations(animations []*Animation) error {
+	if opts.strict && len(animations) == 0 {
+		return ErrNoAnimations
+	}
+	return nil
+}
+
+// isCSV reports whether the -input file at path is a CSV table of names rather than resolved animations.
+func isCSV(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".csv")
+}
+
+// readFromFolders merges the animations of every folder, in order, leaving animations of the same name to dropDuplicates.
+// Folders are cleaned and each is read once, so `animations/` and `./animations` are the same folder, and a folder with glob
+// characters such as `animations/*` is expanded, reading the files it matches directly.
+// It fails if any of the folders doesn't exist or a glob matches nothing, and with -strict if any path can't be read.
+func readFromFolders(folders []string) ([]*Animation, error) {
+	var animations []*Animation
+	read := make(map[string]bool)
+	for _, folder := range folders {
+		folder = filepath.Clean(folder)
+
+		paths := []string{folder}
+		if strings.ContainsAny(folder, "*?[") {
+			matches, err := filepath.Glob(folder)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", folder, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files or folders match %s", folder)
+			}
+			paths = matches
+		} else {
+			info, err := os.Stat(folder)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				return nil, fmt.Errorf("%s is not a folder", folder)
+			}
+		}
+
+		for _, path := range paths {
+			if absolute, err := filepath.Abs(path); err == nil {
+				if read[absolute] {
+					continue
+				}
+				read[absolute] = true
+			}
+			found, err := readFromFolder(path)
+			if err != nil {
+				return nil, err
+			}
+			animations = append(animations, found...)
+		}
+	}
+	return animations, nil
+}
+
+// dropDuplicates keeps the first animation of each name, warning about the others, such as a clip exported
+// both as `A_intro_01.anim` and `A_intro_01.fbx`. With -strict, a duplicate name is an error wrapping ErrDuplicateAnimation.
+func dropDuplicates(animations []*Animation) ([]*Animation, error) {
+	seen := make(map[string]bool, len(animations))
+	kept := animations[:0]
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		if seen[animation.Name] {
+			if opts.strict {
+				return nil, &NameError{Name: animation.Name, Err: ErrDuplicateAnimation}
+			}
+			fmt.Fprintf(os.Stderr, "warning: skipping duplicate animation %s\n", animation.Name)
+			continue
+		}
+		seen[animation.Name] = true
+		kept = append(kept, animation)
+	}
+	return kept, nil
+}
+
+// readFromManifest reads one animation name per line of the file at path.
+// Blank lines are skipped and everything after a `#` is a comment.
+// Names are trimmed but otherwise kept as is: names with spaces or other characters outside of the letters, digits
+// and separators of the naming pattern are loaded without relations, and exporters quote them like any other name.
+func readFromManifest(path string) ([]*Animation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var animations []*Animation
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		name := strings.TrimSpace(line)
+		if name == "" {
+			continue
+		}
+		animations = append(animations, &Animation{Name: name})
+	}
+	return animations, scanner.Err()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates an empty file at each of the paths relative to dir.
+func writeFiles(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// names returns the names of the animations.
+func names(animations []*Animation) []string {
+	var names []string
+	for _, animation := range animations {
+		names = append(names, animation.Name)
+	}
+	return names
+}
+
+func TestReadFromFolderWalkError(t *testing.T) {
+	withOpts(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, "A_intro_01.anim")
+	// Following the dangling link fails with the error and a nil info passed to the walk function
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	opts.followSymlinks = true
+
+	animations, err := readFromFolder(dir)
+	if err != nil {
+		t.Fatalf("reading with a broken link: %v", err)
+	}
+	if got := names(animations); !equalNames(got, []string{"A_intro_01"}) {
+		t.Errorf("read %q, want only A_intro_01", got)
+	}
+	if _, err := readFromFolder(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("reading a missing root without -strict: %v", err)
+	}
+
+	opts.strict = true
+	if _, err := readFromFolder(dir); err == nil {
+		t.Error("reading with a broken link and -strict should fail")
+	}
+	if _, err := readFromFolder(filepath.Join(dir, "missing")); err == nil {
+		t.Error("reading a missing root with -strict should fail")
+	}
+}
+
+func TestReadFromFolderSymlinks(t *testing.T) {
+	withOpts(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, "shared/A_relax_01.anim", "animations/A_intro_01.anim")
+	if err := os.Symlink(filepath.Join(dir, "shared"), filepath.Join(dir, "animations", "linked")); err != nil {
+		t.Fatal(err)
+	}
+	// A link back to the folder itself is walked once
+	if err := os.Symlink(filepath.Join(dir, "animations"), filepath.Join(dir, "animations", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	animations, err := readFromFolder(filepath.Join(dir, "animations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(animations); contains(got, "A_relax_01") {
+		t.Errorf("read %q without -follow-symlinks, want the linked folder left out", got)
+	}
+
+	opts.followSymlinks = true
+	animations, err = readFromFolder(filepath.Join(dir, "animations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(animations); !equalNames(got, []string{"A_intro_01", "A_relax_01"}) {
+		t.Errorf("read %q with -follow-symlinks, want A_intro_01 and A_relax_01", got)
+	}
+}
+
+func TestDuplicateNames(t *testing.T) {
+	withOpts(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, "f3/A_x_01.fbx", "f3/A_x_01.anim", "f3/A_x_02.anim", "other/A_x_02.anim")
+
+	for _, folders := range [][]string{
+		{filepath.Join(dir, "f3")},
+		{filepath.Join(dir, "f3"), filepath.Join(dir, "other")},
+	} {
+		opts.strict = false
+		read, err := readFromFolders(folders)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kept, err := dropDuplicates(read)
+		if err != nil {
+			t.Fatalf("dropping duplicates of %q: %v", folders, err)
+		}
+		if got := names(kept); !equalNames(got, []string{"A_x_01", "A_x_02"}) {
+			t.Errorf("kept %q from %q, want A_x_01 and A_x_02", got, folders)
+		}
+
+		opts.strict = true
+		read, err = readFromFolders(folders)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dropDuplicates(read); !errors.Is(err, ErrDuplicateAnimation) {
+			t.Errorf("reading %q with -strict gave %v, want ErrDuplicateAnimation", folders, err)
+		}
+	}
+
+	// The same folder given twice is read once
+	opts.strict = true
+	read, err := readFromFolders([]string{filepath.Join(dir, "other"), filepath.Join(dir, "other") + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dropDuplicates(read); err != nil {
+		t.Errorf("reading the same folder twice with -strict: %v", err)
+	}
+}
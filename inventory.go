@@ -0,0 +1,55 @@
+package main
+
+import "sort"
+
+// Inventory lists the distinct actions and characters found in the names.
+type Inventory struct {
+	Actions    []string        `json:"actions"`
+	Characters []string        `json:"characters"`
+	Counts     InventoryCounts `json:"counts"`
+}
+
+// InventoryCounts holds the number of clips of every action and character.
+type InventoryCounts struct {
+	Actions    map[string]int `json:"actions"`
+	Characters map[string]int `json:"characters"`
+	// Unparsed is the number of names that don't match the naming pattern.
+	Unparsed int `json:"unparsed"`
+}
+
+// takeInventory parses every name and collects its action and character.
+func takeInventory(animations []*Animation) Inventory {
+	inventory := Inventory{
+		Actions:    []string{},
+		Characters: []string{},
+		Counts: InventoryCounts{
+			Actions:    make(map[string]int),
+			Characters: make(map[string]int),
+		},
+	}
+
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		parsed, err := ParseName(animation.Name)
+		if err != nil {
+			inventory.Counts.Unparsed++
+			continue
+		}
+		if inventory.Counts.Actions[parsed.Action] == 0 {
+			inventory.Actions = append(inventory.Actions, parsed.Action)
+		}
+		inventory.Counts.Actions[parsed.Action]++
+		if parsed.Char != "" {
+			if inventory.Counts.Characters[parsed.Char] == 0 {
+				inventory.Characters = append(inventory.Characters, parsed.Char)
+			}
+			inventory.Counts.Characters[parsed.Char]++
+		}
+	}
+
+	sort.Strings(inventory.Actions)
+	sort.Strings(inventory.Characters)
+	return inventory
+}
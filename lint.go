@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// lintRule rewrites a name towards the naming pattern, returning it unchanged when the rule doesn't apply.
+type lintRule struct {
+	name        string
+	description string
+	fix         func(name string) string
+}
+
+// lintRules are the rules tried by -lint, in order. Each works on the output of the previous one.
+var lintRules = []lintRule{
+	{"whitespace", "spaces should be separators", func(name string) string {
+		return strings.Join(strings.Fields(name), profile.Separator)
+	}},
+	{"repeated-separator", "separators shouldn't repeat", func(name string) string {
+		double := profile.Separator + profile.Separator
+		for strings.Contains(name, double) {
+			name = strings.ReplaceAll(name, double, profile.Separator)
+		}
+		return name
+	}},
+	{"missing-separator", "the action should be separated from the clip number", func(name string) string {
+		missing := regexp.MustCompile("^(" + regexp.QuoteMeta(profile.Prefix+profile.Separator) + "[a-zA-Z]+)([0-9])")
+		return missing.ReplaceAllString(name, "${1}"+strings.ReplaceAll(profile.Separator, "$", "$$")+"${2}")
+	}},
+	{"action-case", "the action should be lowercase", func(name string) string {
+		tokens := strings.Split(name, profile.Separator)
+		action := regexp.MustCompile("^(?:" + profile.ActionPattern + ")$")
+		if len(tokens) > 1 && !action.MatchString(tokens[1]) && action.MatchString(strings.ToLower(tokens[1])) {
+			tokens[1] = strings.ToLower(tokens[1])
+		}
+		return strings.Join(tokens, profile.Separator)
+	}},
+	{"clip-padding", "clip numbers should be padded to the clip width", func(name string) string {
+		tokens := strings.Split(name, profile.Separator)
+		for i, token := range tokens {
+			if i > 0 && token != "" && len(token) < profile.ClipWidth && strings.Trim(token, "0123456789") == "" {
+				tokens[i] = profile.clip(atoi(token))
+			}
+		}
+		return strings.Join(tokens, profile.Separator)
+	}},
+}
+
+// lint suggests a name matching the naming pattern for every animation that doesn't match it,
+// along with the rules that fired to get there. Names no rule can fix are reported without a suggestion.
+func lint(animations []*Animation) []Issue {
+	var issues []Issue
+	for _, animation := range animations {
+		if animation == nil || !unparseable(animation.Name) {
+			continue
+		}
+
+		fixed := animation.Name
+		var fired []string
+		for _, rule := range lintRules {
+			if next := rule.fix(fixed); next != fixed {
+				fixed = next
+				fired = append(fired, fmt.Sprintf("%s: %s", rule.name, rule.description))
+			}
+		}
+
+		message := "no suggestion, the name doesn't match the naming pattern"
+		if len(fired) > 0 && !unparseable(fixed) {
+			message = fmt.Sprintf("should be %s (%s)", fixed, strings.Join(fired, "; "))
+		}
+		issues = append(issues, Issue{
+			Check:   "lint",
+			Name:    animation.Name,
+			Message: message,
+		})
+	}
+	return issues
+}
+
+func unparseable(name string) bool {
+	_, err := ParseName(name)
+	return err != nil
+}
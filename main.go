@@ -3,64 +3,482 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// Animation is a clip and the clips it's related to.
+// The JSON tags fix the names of the output fields, keep them when renaming or reordering fields.
 type Animation struct {
-	Name                string
-	NextAnimations      []string
-	AlternateAnimations []string
-	PreviousAnimation   string
+	// ID is a short stable hash of Name. Only set with -with-ids.
+	ID                  string   `json:"ID,omitempty"`
+	Name                string   `json:"Name"`
+	NextAnimations      []string `json:"NextAnimations"`
+	AlternateAnimations []string `json:"AlternateAnimations"`
+	PreviousAnimation   string   `json:"PreviousAnimation"`
+	// Tag is the trailing tag of the name, such as `loop` in `A_intro_01_loop`.
+	Tag string `json:"Tag,omitempty"`
+	// RandomNext is set when NextAnimations is a pool of alternates to pick from at random. Only set with -alts-as-next.
+	RandomNext bool `json:"RandomNext,omitempty"`
+	// ClipIndex is the parsed clip number, or -1 if the name couldn't be parsed. Only set with -clip-index.
+	ClipIndex *int `json:"ClipIndex,omitempty"`
+	// Depth is the fewest next animations leading to the clip from one of the Roots, or -1 if none does. Only set with -depth.
+	Depth *int `json:"Depth,omitempty"`
+	// Members is the alternate family merged into the clip, itself included. Only set with -collapse-alternates.
+	Members []string `json:"Members,omitempty"`
+	// Path is the file the animation was read from. Only set with -with-paths, for animations read from a folder.
+	Path string `json:"Path,omitempty"`
+	// Unparsed is set when the name doesn't match the naming pattern. Only set with -mark-unparsed.
+	Unparsed bool `json:"Unparsed,omitempty"`
+	// Relations maps the custom relation kinds added with RegisterRelation to the related animations.
+	Relations map[string][]string `json:"Relations,omitempty"`
+
+	// selfLoop is set when the clip resolved itself as its next animation.
+	selfLoop bool
+	// reasons records why each of the NextAnimations was resolved.
+	reasons map[string]Reason
+	// modTime is the modification time of the file the animation was read from, zero when not read from a folder.
+	modTime time.Time
+	// path is the file the animation was read from, empty when not read from a folder.
+	path string
+	// groups are the parts given alongside the name by a CSV table with -trust-columns, used to resolve the clip
+	// instead of matching its name again, or nil to match it. They're only set when they agree with the name.
+	groups Groups
+	// weight is the selection weight read from a weights sidecar with -weights, if weighted is set.
+	weight   float64
+	weighted bool
 }
 
 func main() {
-	animations := readFromFolder()
+	if err := parseFlags(); err != nil {
+		fatal(err)
+	}
+
+	if opts.clearCache {
+		if err := clearCache(); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	animations, err := loadAnimations()
+	if err != nil {
+		fatal(err)
+	}
+	if err := requireAnimations(animations); err != nil {
+		fatal(err)
+	}
+	if opts.maxAnimations > 0 && len(animations) > opts.maxAnimations {
+		fatal(fmt.Errorf("found %d animations, more than -max-animations %d, check the folder or manifest", len(animations), opts.maxAnimations))
+	}
+	onDisk := make(map[string]bool, len(animations))
+	for _, animation := range animations {
+		onDisk[animation.Name] = true
+	}
+
+	if !opts.validate && !opts.validateStrict && !opts.lint {
+		// -validate reports these as issues instead
+		for _, issue := range findUnparseableNames(animations) {
+			fmt.Fprintf(os.Stderr, "warning: %s doesn't match the naming pattern and won't have any relations\n", issue.Name)
+		}
+	}
+
+	if opts.explain != "" {
+		explain(os.Stdout, opts.explain, sortAnimations(animations))
+		return
+	}
+
+	if opts.lint {
+		issues := lint(animations)
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.completeness {
+		report := checkCompleteness(animations)
+		bytes, _ := json.Marshal(report)
+		fmt.Println(string(bytes))
+		for _, entry := range report {
+			if len(entry.Missing) > 0 {
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	if opts.inventory {
+		bytes, _ := json.Marshal(takeInventory(animations))
+		fmt.Println(string(bytes))
+		return
+	}
+
+	var set *AnimationSet
+	switch {
+	case opts.lazy:
+		set = NewLazyAnimationSet(animations)
+		animations = set.Animations
+	case inputResolved():
+		// Resolved by an earlier run
+	case opts.noCache:
+		animations = fetchAnimations(animations)
+	default:
+		animations = cachedFetchAnimations(animations)
+	}
+
+	if opts.altsAsNext {
+		alternatesAsNext(animations)
+	}
+
+	if opts.collapseTransitions {
+		collapseTransitions(animations)
+	}
+
+	if opts.excludeTransitions {
+		animations = excludeTransitions(animations)
+	}
+
+	if opts.collapseAlternates {
+		animations = collapseAlternates(animations)
+	}
+
+	if opts.firstOnly {
+		firstOnly(animations)
+	}
+
+	if opts.withIDs {
+		setIDs(animations)
+	}
+
+	if opts.clipIndex {
+		setClipIndices(animations)
+	}
+
+	if opts.depth {
+		setDepths(animations)
+	}
+
+	if opts.withPaths {
+		setPaths(animations)
+	}
+
+	if opts.markUnparsed {
+		setUnparsed(animations)
+	}
+
+	if opts.exportIndex != "" {
+		if err := writeIndexFile(opts.exportIndex, animations); err != nil {
+			fatal(err)
+		}
+	}
+
+	if set == nil {
+		set = indexSet(animations)
+	}
+	set.passes = patternPasses()
+
+	if opts.validateStrict {
+		report := validateStrict(animations, onDisk)
+		bytes, _ := json.Marshal(report)
+		fmt.Println(string(bytes))
+		if report.Failed() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.sequence != "" {
+		sequence, err := Sequence(opts.sequence, set)
+		if err != nil {
+			fatal(err)
+		}
+		bytes, _ := json.Marshal(sequence)
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.into != "" {
+		sources, err := set.Into(opts.into)
+		if err != nil {
+			fatal(err)
+		}
+		bytes, _ := json.Marshal(sources)
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.transitionOnlyEntries {
+		bytes, _ := json.Marshal(set.TransitionOnlyEntries())
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.longest {
+		sequence, err := LongestSequence(animations)
+		if err != nil {
+			fatal(err)
+		}
+		bytes, _ := json.Marshal(sequence)
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.playlist != "" {
+		playlist, err := Playlist(opts.playlist, set, rand.New(rand.NewSource(opts.seed)))
+		if err != nil {
+			fatal(err)
+		}
+		bytes, _ := json.Marshal(playlist)
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.leaves {
+		bytes, _ := json.Marshal(FindLeaves(animations))
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.pools {
+		bytes, _ := json.Marshal(StandalonePools(animations))
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.roots {
+		bytes, _ := json.Marshal(Roots(animations))
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.graphStats {
+		bytes, _ := json.Marshal(graphStats(set))
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.sequences != "" {
+		sequences, err := Sequences(opts.sequences, set)
+		if err != nil {
+			fatal(err)
+		}
+		bytes, _ := json.Marshal(sequences)
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if opts.serve != "" {
+		fmt.Fprintf(os.Stderr, "serving %d animations on %s\n", len(animations), opts.serve)
+		if err := http.ListenAndServe(opts.serve, newServer(set)); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if opts.repl {
+		runREPL(set, os.Stdin, os.Stdout)
+		return
+	}
 
-	animations = fetchAnimations(animations)
+	output := animations
+	if opts.subgraphFrom != "" {
+		if output, err = subgraphFrom(opts.subgraphFrom, output); err != nil {
+			fatal(err)
+		}
+	}
+	if !opts.since.IsZero() {
+		output = changedSince(output, opts.since)
+	}
+	if opts.where != nil {
+		output = FilterByFields(opts.where, output)
+	}
+	if opts.pathsAsRefs {
+		output = pathsAsRefs(output, animations)
+	}
+	if err := formats[opts.format](os.Stdout, output); err != nil {
+		fatal(err)
+	}
 
-	bytes, _ := json.Marshal(animations)
-	toPrint := string(bytes)
-	fmt.Println(toPrint)
+	var issues []Issue
+	if opts.validate {
+		issues = append(issues, validate(animations)...)
+	}
+	if opts.verifyFiles {
+		issues = append(issues, verifyFiles(animations, onDisk)...)
+	}
+	if opts.noBranch {
+		issues = append(issues, findBranches(animations)...)
+	}
+	if opts.expect != "" {
+		expected, err := readExpected(opts.expect)
+		if err != nil {
+			fatal(err)
+		}
+		issues = append(issues, compareExpected(animations, expected)...)
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(os.Stderr, issue)
+	}
+	if len(issues) > 0 {
+		os.Exit(1)
+	}
+}
+
+// fatal prints err to stderr and exits with a non-zero status.
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
 
-func readFromFolder() []*Animation {
+// readFromFolder returns an animation for every file under root, named after the file without its extension.
+// Paths that can't be read are skipped with a warning, or fail the walk with -strict.
+// With -weights, the weights sidecars are read instead of being taken for animations.
+// With -follow-symlinks, symlinked folders are walked too, each real folder once, so links looping back are harmless.
+func readFromFolder(root string) ([]*Animation, error) {
 	var animations []*Animation
-	filepath.Walk("animations", func(path string, info os.FileInfo, err error) error {
+	weights := make(map[string]float64)
+	visited := make(map[string]bool)
+	discovered := startProgress("files discovered")
+	defer discovered.stop()
+
+	var walk filepath.WalkFunc
+	walk = func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// info is nil when the path itself couldn't be read, such as on a permission error
+			if opts.strict {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "warning: skipping %s: %v\n", path, err)
+			return nil
+		}
 		if info.IsDir() {
+			if !opts.followSymlinks {
+				return nil
+			}
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return walk(path, nil, err)
+			}
+			if visited[real] {
+				return filepath.SkipDir
+			}
+			visited[real] = true
 			return nil
 		}
+		if opts.followSymlinks && info.Mode()&os.ModeSymlink != 0 {
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return walk(path, nil, err)
+			}
+			target, err := os.Stat(real)
+			if err != nil {
+				return walk(path, nil, err)
+			}
+			if target.IsDir() {
+				return filepath.Walk(real, walk)
+			}
+		}
+		if opts.weights && isWeightsFile(info.Name()) {
+			sidecar, err := readWeights(path)
+			if err != nil {
+				return err
+			}
+			for name, weight := range sidecar {
+				weights[name] = weight
+			}
+			return nil
+		}
+		discovered.add()
 		// filename without extension
 		filename := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
-		animations = append(animations, &Animation{Name: filename})
+		animations = append(animations, &Animation{Name: filename, modTime: info.ModTime(), path: path})
 		return nil
-	})
-	return animations
+	}
+	err := filepath.Walk(root, walk)
+	assignWeights(animations, weights)
+	return animations, err
 }
 
+// Group is a capture group of the naming pattern, named in groupNames.
+type Group int
+
 const (
-	action       = "action"
-	char         = "char"
-	clipNumber   = "clip"
-	alternate    = "alternate"
-	transitionTo = "transitionTo"
-	nextName     = "nextName"
-	nextClip     = "nextClip"
+	GroupAction Group = iota
+	GroupChar
+	GroupClip
+	GroupAlternate
+	GroupTag
+	GroupTransitionTo
+	GroupNextName
+	GroupNextChar
+	GroupNextClip
+	// GroupID is only part of the pattern of profiles with a numeric prefix.
+	GroupID
+	// GroupBidirectional is only part of the pattern of profiles with a bidirectional separator.
+	GroupBidirectional
+
+	groupCount
 )
 
+// groupNames are the names of the groups in the naming pattern.
+var groupNames = [groupCount]string{
+	GroupAction:        "action",
+	GroupChar:          "char",
+	GroupClip:          "clip",
+	GroupAlternate:     "alternate",
+	GroupTag:           "tag",
+	GroupTransitionTo:  "transitionTo",
+	GroupNextName:      "nextName",
+	GroupNextChar:      "nextChar",
+	GroupNextClip:      "nextClip",
+	GroupID:            "id",
+	GroupBidirectional: "bidirectional",
+}
+
+func (group Group) String() string {
+	return groupNames[group]
+}
+
+// groupNamed returns the group called name, reporting whether there is one.
+func groupNamed(name string) (Group, bool) {
+	for group, groupName := range groupNames {
+		if groupName == name {
+			return Group(group), true
+		}
+	}
+	return 0, false
+}
+
 // re is the regular expression for parsing the animation name.
 // The `A` at the beginning is for "Animation".
+// id is the numeric ID replacing the `A` with a numeric prefix, such as `001` in `001_intro_01`. (optional)
 // action is the name of the animation.
-// char is the character name. (optional)
+// char is the character name, the separators around it being optional, so `A_introX01` and `A_intro_X_01` have the same. (optional)
 // clip is clipNumber.
 // alternate is the alternate animation letter. (optional)
+// The clip number always sits between the two letters, so `A_intro_X_01A` has the char `X` and the alternate `A`
+// and is an alternate of `A_intro_X_01B` and `A_intro_X_01_C`.
+// tag is a trailing lowercase word such as `loop` in `A_intro_01_loop`, only at the end of the name. (optional)
 // transitionTo is the animation name to transition to. (optional)
 // nextName is the next animation name to transition to. (optional)
+// nextChar is the character of the animation to transition to, such as `Y` in `A_intro_X_01-combat_Y_01`. (optional)
 // nextClip is the next animation clip to transition to. (optional)
-var re = regexp.MustCompile(`A_(?P<action>[a-z]+)_(?:(?P<char>[A-Z]?)_?(?P<clip>\d{2}))_?(?P<alternate>[A-Z]?)?-?(?P<transitionTo>(?P<nextName>[a-z]+)?_?(?P<nextClip>\d{2}))?`)
+// bidirectional is the bidirectional separator, such as `<->` in `A_intro_01<->relax_01`,
+// only when the profile has one. (optional)
+// re is built from the active profile, this is the default one.
+var re = regexp.MustCompile(`A_(?P<action>[a-z]+)(?:(?:_?(?P<char>[A-Z])_?|_)(?P<clip>\d{2}))_?(?:(?P<tag>[a-z]+)$|(?P<alternate>[A-Z]?)?)-?(?P<transitionTo>(?P<nextName>[a-z]+)?_?(?P<nextChar>[A-Z]?)_?(?P<nextClip>\d{2}))?`)
 
 // fetchAnimations returns all the possible next animations.
 // The `A` at the beginning is for "Animation".
@@ -77,21 +495,34 @@ var re = regexp.MustCompile(`A_(?P<action>[a-z]+)_(?:(?P<char>[A-Z]?)_?(?P<clip>
 // An example is `A_intro_01` -> `A_intro_01B` -> `A_intro_01C`
 // There's also a special case such as `A_animation_A_01` and `A_animation_B_01`, which distinguishes from two characters.
 // In this case, they are not alternate animations, but two different animations.
+// The animations are sorted by name first so the chosen next, previous and alternate
+// animations don't depend on the order the folder was walked in.
 func fetchAnimations(animations []*Animation) []*Animation {
+	animations = sortAnimations(animations)
+
+	index := indexSet(animations)
+
+	resolved := startProgress("animations resolved")
+	defer resolved.stop()
 	for _, animation := range animations {
 		//break
 		if animation == nil {
 			continue
 		}
-		animation.getNextAnimation(animations)
-		animation.getAlternateAnimation(animations)
+		animation.getNextAnimation(index)
+		animation.getAlternateAnimation(index)
+		animation.getRelations(index)
+		if parsed, err := ParseName(animation.Name); err == nil {
+			animation.Tag = parsed.Tag
+		}
+		resolved.add()
 	}
 
 	for _, animation := range animations {
 		if animation == nil {
 			continue
 		}
-		animation.getPreviousAnimation(animations)
+		animation.getPreviousAnimation(index)
 	}
 
 	return animations
@@ -99,77 +530,151 @@ func fetchAnimations(animations []*Animation) []*Animation {
 
 // getNextAnimation returns the next animation in the sequence.
 // If there is no next animation, then it returns nil.
-func (clip *Animation) getNextAnimation(allAnimations []*Animation) {
-	match := re.FindStringSubmatch(clip.Name)
-	if match == nil {
+// Only the primary clip of an alternate family advances: `A_intro_01` and `A_intro_01_A` (or `A_intro_01A`) get
+// `A_intro_02`, while `A_intro_01_B` gets nothing and is played in place of the primary clip instead.
+// getPreviousAnimation doesn't follow this rule, non-primary alternates go back to the previous clip too.
+// Clips tagged with the profile end marker, such as `A_intro_99_end` with -end-marker end, don't advance either.
+func (clip *Animation) getNextAnimation(index Index) {
+	result := clip.match()
+	if result == nil {
 		return
 	}
 
-	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
-		result[name] = match[i]
+	if result.Alternate() != "" && result.Alternate() != "A" {
+		// Alternate clips don't have next animations, but use alternate animations instead unless it's the first clip (A)
+		return
 	}
 
-	if result[alternate] != "" && result[alternate] != "A" {
-		// Alternate clips don't have next animations, but use alternate animations instead unless it's the first clip (A)
+	if profile.EndMarker != "" && result.Tag() == profile.EndMarker {
+		// The sequence ends here, even if a clip with the next clip number exists
 		return
 	}
 
 	// Check for transition animations first
-	if result[transitionTo] != "" {
-		clip.findTransition(allAnimations, result)
+	if result.TransitionTo() != "" {
+		clip.findTransition(index, result)
 		return
 	}
 
-	nextClipName := fmt.Sprintf("A_%s_%02d", result[action], atoi(result[clipNumber])+1)
-	if result[char] != "" {
-		nextClipName = fmt.Sprintf("A_%s_%s_%02d", result[action], result[char], atoi(result[clipNumber])+1)
-	}
+	nextClipName := profile.name(result.Action(), result.Char(), profile.clip(atoi(result.Clip())+1))
 
-	// Try searching for clips with transitionTo (e.g., 01 -> 01-02)
-	nextClip := findAnimationByName(fmt.Sprintf("^%s-", strings.TrimSuffix(match[0], "_A")), allAnimations)
+	// Try searching for clips with transitionTo (e.g., 01 -> 01-02, 01_A -> 01-02, 01A -> 01-02)
+	// The base is rebuilt from the parsed parts, so the primary alternate letter is dropped however it's spaced.
+	base := profile.name(result.Action(), result.Char(), result.Clip())
+	reason := ReasonTransition
+	nextClip := findAnimationByName(profile.transitions(base), index)
 
 	if nextClip == nil {
 		// Try appending "A" or "_A" to the end (e.g., 01 -> 02, 01 -> 02A, 01 -> 02_A)
-		nextClip = findAnimationByName(fmt.Sprintf("^%s_?A?$", nextClipName), allAnimations)
+		reason = ReasonSequential
+		nextClip = findAnimationByName(profile.primary(nextClipName), index)
 	}
 
 	if nextClip != nil {
-		clip.NextAnimations = append(clip.NextAnimations, nextClip.Name)
+		clip.addNext(nextClip.Name, reason)
 	}
-}
 
-func (clip *Animation) findTransition(allAnimations []*Animation, result map[string]string) {
-	// No nextName means transition (e.g., 01-02)
-	if result[nextName] == "" {
-		// Transition within the same group but different clip
-		nextClipName := fmt.Sprintf("A_%s_%s", result[action], result[nextClip])
-		if result[char] != "" {
-			// TODO: Change char regex to also capture the underscore so we can always attempt to concatenate
-			nextClipName = fmt.Sprintf("A_%s_%s_%s", result[action], result[char], result[nextClip])
+	if profile.BidirectionalSeparator != "" {
+		// Bidirectional transitions ending at this clip are also played backwards from it, leading to the clip
+		// they start at (e.g., relax_01 -> intro_01 through intro_01<->relax_01), along with its regular next animation
+		for _, transition := range findBidirectionalsInto(clip.Name, index) {
+			if source := bidirectionalSource(MatchGroups(transition.Name), index); source != nil && !contains(clip.NextAnimations, source.Name) {
+				clip.addNext(source.Name, ReasonTransitionReverse)
+			}
 		}
-		nextClip := findAnimationByName(fmt.Sprintf("^%s_?A?$", nextClipName), allAnimations)
+	}
+}
 
-		if nextClip != nil {
-			clip.NextAnimations = append(clip.NextAnimations, nextClip.Name)
-		}
+// addNext appends name to the next animations unless it's the clip itself, recording why it was resolved.
+// A regex edge case such as `^A_intro_01_?A?$` matching `A_intro_01` would otherwise make the clip loop into itself.
+func (clip *Animation) addNext(name string, reason Reason) {
+	if name == clip.Name {
+		clip.selfLoop = true
 		return
 	}
+	clip.NextAnimations = append(clip.NextAnimations, name)
+	if clip.reasons == nil {
+		clip.reasons = make(map[string]Reason)
+	}
+	clip.reasons[name] = reason
+}
 
-	// With nextName (e.g., 02-relax_01)
-	nextClipName := fmt.Sprintf("A_%s", result[transitionTo])
-
-	nextClip := findAnimationByName(fmt.Sprintf("^%s_?A?$", nextClipName), allAnimations)
+// findTransition adds the clip the transition parsed into result leads to.
+// A bidirectional transition only leads to its target too: the clip it starts at is a next animation of the target instead,
+// so that playing it never leads back to the clip it was entered from.
+func (clip *Animation) findTransition(index Index, result Groups) {
+	nextClipName, reason := transitionTarget(result)
+	nextClip := findAnimationByName(profile.primary(nextClipName), index)
 
 	if nextClip != nil {
-		clip.NextAnimations = append(clip.NextAnimations, nextClip.Name)
+		clip.addNext(nextClip.Name, reason)
 	}
-	return
 }
 
-func findAnimationByName(expression string, allAnimations []*Animation) *Animation {
+// bidirectionalSource returns the clip the bidirectional transition parsed into result starts at, if any.
+func bidirectionalSource(result Groups, index Index) *Animation {
+	return findAnimationByName(profile.primary(profile.name(result.Action(), result.Char(), result.Clip())), index)
+}
+
+// transitionTarget returns the name of the clip the transition parsed into result leads to, and why.
+func transitionTarget(result Groups) (string, Reason) {
+	// No nextName means transition (e.g., 01-02)
+	if result.NextName() == "" {
+		// Transition within the same group but different clip, and to another character with nextChar (e.g., X_01-Y_02)
+		char := result.Char()
+		if result.NextChar() != "" {
+			char = result.NextChar()
+		}
+		return profile.name(result.Action(), char, result.NextClip()), ReasonTransitionSameGroup
+	}
+
+	// With nextName (e.g., 02-relax_01), and the character of the other group with nextChar (e.g., X_01-combat_Y_01)
+	return profile.name(result.NextName(), result.NextChar(), result.NextClip()), ReasonTransitionCrossGroup
+}
+
+// findBidirectionalsInto returns the bidirectional transitions leading to the clip called name.
+// Every bidirectional transition is checked, since their names start with the clip at their other end.
+func findBidirectionalsInto(name string, index Index) []*Animation {
+	var into []*Animation
+	for _, candidate := range filterAnimations(profile.bidirectionals(), index) {
+		result := MatchGroups(candidate.Name)
+		if result == nil || result.Bidirectional() == "" {
+			continue
+		}
+		target, _ := transitionTarget(result)
+		if regexp.MustCompile(profile.primary(target)).MatchString(name) {
+			into = append(into, candidate)
+		}
+	}
+	return into
+}
+
+// sortAnimations returns a copy of animations sorted by name, with nil entries moved to the end.
+func sortAnimations(animations []*Animation) []*Animation {
+	sorted := make([]*Animation, len(animations))
+	copy(sorted, animations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i] == nil || sorted[j] == nil {
+			return sorted[j] == nil && sorted[i] != nil
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func findAnimationByName(expression string, index Index) *Animation {
 	reg := regexp.MustCompile(expression)
-	for _, anim := range allAnimations {
+	if trace != nil {
+		matched := matchAnimations(reg, candidates(reg, index))
+		if len(matched) > 0 {
+			traceLookup(expression, matched, matched[0])
+			return matched[0]
+		}
+		traceLookup(expression, nil, nil)
+		return nil
+	}
+
+	for _, anim := range candidates(reg, index) {
 		if anim == nil {
 			continue
 		}
@@ -180,10 +685,18 @@ func findAnimationByName(expression string, allAnimations []*Animation) *Animati
 	return nil
 }
 
-func filterAnimations(expression string, allAnimations []*Animation) []*Animation {
-	var filtered []*Animation
+func filterAnimations(expression string, index Index) []*Animation {
 	reg := regexp.MustCompile(expression)
-	for _, anim := range allAnimations {
+	filtered := matchAnimations(reg, candidates(reg, index))
+	if trace != nil {
+		traceLookup(expression, filtered, nil)
+	}
+	return filtered
+}
+
+func matchAnimations(reg *regexp.Regexp, animations []*Animation) []*Animation {
+	var filtered []*Animation
+	for _, anim := range animations {
 		if anim == nil {
 			continue
 		}
@@ -194,70 +707,85 @@ func filterAnimations(expression string, allAnimations []*Animation) []*Animatio
 	return filtered
 }
 
+// atoi returns the clip number str, or 0 if it isn't one. Names with clip numbers out of range never match,
+// see clipNumber, so the numbers it's given always fit.
 func atoi(str string) int {
-	i, _ := strconv.Atoi(str)
+	i, _ := clipNumber(str)
 	return i
 }
 
+// clipNumber parses the clip number str, an empty one being 0. It returns an error wrapping strconv.ErrRange
+// for numbers whose next clip number wouldn't fit in an int on 32-bit platforms, such as a 12-digit clip number.
+func clipNumber(str string) (int, error) {
+	if str == "" {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(str, 10, 32)
+	if err == nil && i == math.MaxInt32 {
+		err = &strconv.NumError{Func: "ParseInt", Num: str, Err: strconv.ErrRange}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(i), nil
+}
+
 // getPreviousAnimation returns the previous animation in the sequence.
 // Example: `A_intro_02` -> `A_intro_01`
 // We should not use the `A_intro_01-02` transition animation because we can't play transition animations backwards.
 // We should also not use the `A_intro_01_A` alternate animation because it's not the previous animation.
-func (clip *Animation) getPreviousAnimation(allAnimations []*Animation) {
-	match := re.FindStringSubmatch(clip.Name)
-	if match == nil {
+// Alternate clips other than the first one (A) go back to the same alternate of the previous clip when it exists,
+// and to the primary previous clip otherwise: `A_intro_02_B` -> `A_intro_01_B`, or `A_intro_01` without it.
+// Clips at the profile base, such as `A_intro_01` with -base 1, have no previous animation.
+func (clip *Animation) getPreviousAnimation(index Index) {
+	result := clip.match()
+	if result == nil {
 		return
 	}
 
-	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
-		result[name] = match[i]
-	}
-
-	if result[transitionTo] != "" {
+	if result.TransitionTo() != "" {
 		// Transition animations don't have previous animations
 		return
 	}
 
-	if result[alternate] != "" && result[alternate] != "A" {
-		// Alternate clips don't have previous animations unless it's the first clip (A)
+	if atoi(result.Clip()) <= profile.Base {
+		// The first clip of a sequence, there's nothing before it even if a clip below the base exists
 		return
 	}
 
-	previousClipName := fmt.Sprintf("A_%s_%02d", result[action], atoi(result[clipNumber])-1)
-	if result[char] != "" {
-		previousClipName = fmt.Sprintf("A_%s_%s_%02d", result[action], result[char], atoi(result[clipNumber])-1)
+	previousClipName := profile.name(result.Action(), result.Char(), profile.clip(atoi(result.Clip())-1))
+
+	var previousClip *Animation
+	if result.Alternate() != "" && result.Alternate() != "A" {
+		// Stay within the same alternate chain when possible (e.g., 02_B -> 01_B, 02B -> 01B)
+		previousClip = findAnimationByName(profile.variant(previousClipName, result.Alternate()), index)
 	}
 
-	previousClip := findAnimationByName(fmt.Sprintf("^%s_?A?$", previousClipName), allAnimations)
+	if previousClip == nil {
+		previousClip = findAnimationByName(profile.primary(previousClipName), index)
+	}
 
 	if previousClip != nil {
 		clip.PreviousAnimation = previousClip.Name
 	}
 }
 
-func (clip *Animation) getAlternateAnimation(allAnimations []*Animation) {
-	match := re.FindStringSubmatch(clip.Name)
-	if match == nil {
+// getAlternateAnimation finds the other members of the clip's alternate family, with or without a separator
+// before their letter, so `A_intro_01_A`, `A_intro_01B` and `A_intro_01_C` all list each other.
+func (clip *Animation) getAlternateAnimation(index Index) {
+	result := clip.match()
+	if result == nil {
 		return
 	}
 
-	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
-		result[name] = match[i]
-	}
-
-	if result[transitionTo] != "" {
+	if result.TransitionTo() != "" {
 		// Transition animations don't have alternate animations
 		return
 	}
 
-	toFind := fmt.Sprintf("A_%s_%s", result[action], result[clipNumber])
-	if result[char] != "" {
-		toFind = fmt.Sprintf("A_%s_%s_%s", result[action], result[char], result[clipNumber])
-	}
+	toFind := profile.name(result.Action(), result.Char(), result.Clip())
 
-	alternates := filterAnimations(fmt.Sprintf("^%s_?[A-Z]?$", toFind), allAnimations)
+	alternates := filterAnimations(profile.alternates(toFind), index)
 	for _, alternate := range alternates {
 		if alternate == nil {
 			continue
@@ -265,6 +793,41 @@ func (clip *Animation) getAlternateAnimation(allAnimations []*Animation) {
 		if alternate.Name == clip.Name {
 			continue
 		}
+		if !sameClip(alternate.Name, result) {
+			// A clip without a character never lists a character's clip such as `A_intro_X_01` and the other way around
+			continue
+		}
 		clip.AlternateAnimations = append(clip.AlternateAnimations, alternate.Name)
 	}
+	sortByAlternate(clip.AlternateAnimations)
+}
+
+// sortByAlternate orders the members of an alternate family by their alternate letter, the clip without one first,
+// whether or not the letter is separated from the clip number: `A_intro_01_A`, `A_intro_01B`, `A_intro_01_C`.
+// Names sort by name after that, so orders stay stable for clips of the same letter.
+func sortByAlternate(names []string) {
+	letter := func(name string) string {
+		parsed, _ := ParseName(name)
+		return parsed.Alternate
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := letter(names[i]), letter(names[j])
+		if a != b {
+			return a < b
+		}
+		return names[i] < names[j]
+	})
+}
+
+// sameClip reports whether name parses to the same action, character, clip number and tag as result, without a transition.
+func sameClip(name string, result Groups) bool {
+	parsed, err := ParseName(name)
+	if err != nil {
+		return false
+	}
+	return parsed.TransitionTo == "" &&
+		parsed.Action == result.Action() &&
+		parsed.Char == result.Char() &&
+		parsed.Clip == result.Clip() &&
+		parsed.Tag == result.Tag()
 }
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// sample is a set covering sequences, alternates, transitions and characters.
+var sample = []string{
+	"A_01",
+	"A_idle_01",
+	"A_idle_01_B",
+	"A_idle_01_C",
+	"A_intro_01",
+	"A_intro_01-02",
+	"A_intro_02",
+	"A_intro_02-relax_01",
+	"A_intro_03",
+	"A_relax_01",
+	"A_relax_02",
+	"A_relax_02C",
+	"A_relax_02_B",
+	"A_wave_X_01",
+	"A_wave_X_02",
+	"A_wave_Y_01",
+}
+
+// animationsOf returns a new unresolved animation for each of names.
+func animationsOf(names ...string) []*Animation {
+	animations := make([]*Animation, len(names))
+	for i, name := range names {
+		animations[i] = &Animation{Name: name}
+	}
+	return animations
+}
+
+// resolve resolves names with fetchAnimations and returns the animations by name.
+func resolve(names ...string) map[string]*Animation {
+	byName := make(map[string]*Animation)
+	for _, animation := range fetchAnimations(animationsOf(names...)) {
+		byName[animation.Name] = animation
+	}
+	return byName
+}
+
+// withProfile makes p the active profile until the test ends.
+func withProfile(t *testing.T, p Profile) {
+	t.Helper()
+	savedProfile, savedRe, savedIndex := profile, re, groupIndex
+	t.Cleanup(func() { profile, re, groupIndex = savedProfile, savedRe, savedIndex })
+	if err := useProfile(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// withOpts restores opts when the test ends, so that it can set the flags it needs.
+func withOpts(t *testing.T) {
+	saved := opts
+	t.Cleanup(func() { opts = saved })
+}
+
+// assertNext fails the test unless the next animations of name in set are want.
+func assertNext(t *testing.T, set map[string]*Animation, name string, want ...string) {
+	t.Helper()
+	animation, ok := set[name]
+	if !ok {
+		t.Fatalf("%s isn't in the set", name)
+	}
+	if !equalNames(animation.NextAnimations, want) {
+		t.Errorf("next of %s = %q, want %q", name, animation.NextAnimations, want)
+	}
+}
+
+// assertPrevious fails the test unless the previous animation of name in set is want.
+func assertPrevious(t *testing.T, set map[string]*Animation, name, want string) {
+	t.Helper()
+	animation, ok := set[name]
+	if !ok {
+		t.Fatalf("%s isn't in the set", name)
+	}
+	if animation.PreviousAnimation != want {
+		t.Errorf("previous of %s = %q, want %q", name, animation.PreviousAnimation, want)
+	}
+}
+
+// assertAlternates fails the test unless the alternate animations of name in set are want.
+func assertAlternates(t *testing.T, set map[string]*Animation, name string, want ...string) {
+	t.Helper()
+	animation, ok := set[name]
+	if !ok {
+		t.Fatalf("%s isn't in the set", name)
+	}
+	if !equalNames(animation.AlternateAnimations, want) {
+		t.Errorf("alternates of %s = %q, want %q", name, animation.AlternateAnimations, want)
+	}
+}
+
+func equalNames(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFetchAnimationsShuffled(t *testing.T) {
+	want, err := json.Marshal(fetchAnimations(animationsOf(sample...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		shuffled := append([]string(nil), sample...)
+		random.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		got, err := json.Marshal(fetchAnimations(animationsOf(shuffled...)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("resolving %q gave\n%s\nwant\n%s", shuffled, got, want)
+		}
+	}
+}
+
+func TestTransitionSearchBase(t *testing.T) {
+	for _, primary := range []string{"A_intro_01_A", "A_intro_01A"} {
+		set := resolve(primary, "A_intro_01-02", "A_intro_02")
+		assertNext(t, set, primary, "A_intro_01-02")
+		assertNext(t, set, "A_intro_01-02", "A_intro_02")
+	}
+
+	set := resolve("A_intro_01_A", "A_intro_02")
+	assertNext(t, set, "A_intro_01_A", "A_intro_02")
+}
+
+func TestAlternatesSameCharacter(t *testing.T) {
+	set := resolve("A_intro_01", "A_intro_01_B", "A_intro_X_01", "A_intro_X_01_B", "A_relax_01_B")
+	assertAlternates(t, set, "A_intro_01", "A_intro_01_B")
+	assertAlternates(t, set, "A_intro_01_B", "A_intro_01")
+	assertAlternates(t, set, "A_intro_X_01", "A_intro_X_01_B")
+	assertAlternates(t, set, "A_relax_01_B")
+}
+
+func TestPreviousAlternateChain(t *testing.T) {
+	set := resolve("A_intro_01", "A_intro_01_B", "A_intro_02", "A_intro_02_B", "A_intro_03_B", "A_intro_03C")
+	assertPrevious(t, set, "A_intro_02_B", "A_intro_01_B")
+	assertPrevious(t, set, "A_intro_03_B", "A_intro_02_B")
+	assertPrevious(t, set, "A_intro_03C", "A_intro_02")
+	assertPrevious(t, set, "A_intro_02", "A_intro_01")
+}
+
+func TestFirstAlternateAdvances(t *testing.T) {
+	set := resolve("A_intro_01", "A_intro_01_A", "A_intro_01_B", "A_intro_02", "A_intro_02_A", "A_intro_02_B")
+	assertNext(t, set, "A_intro_01", "A_intro_02")
+	assertNext(t, set, "A_intro_01_A", "A_intro_02")
+	assertNext(t, set, "A_intro_01_B")
+	assertPrevious(t, set, "A_intro_02", "A_intro_01")
+	assertPrevious(t, set, "A_intro_02_A", "A_intro_01")
+	assertPrevious(t, set, "A_intro_02_B", "A_intro_01_B")
+	for _, name := range []string{"A_intro_01", "A_intro_01_A", "A_intro_01_B"} {
+		assertPrevious(t, set, name, "")
+	}
+
+	// Without the bare clip the A alternate is the primary one
+	set = resolve("A_intro_01_A", "A_intro_01_B", "A_intro_02", "A_intro_02_B")
+	assertNext(t, set, "A_intro_01_A", "A_intro_02")
+	assertNext(t, set, "A_intro_01_B")
+	assertPrevious(t, set, "A_intro_02", "A_intro_01_A")
+	assertPrevious(t, set, "A_intro_02_B", "A_intro_01_B")
+}
+
+// benchmarkNames returns a set of n actions, each with 20 clips, an alternate and a transition.
+func benchmarkNames(n int) []string {
+	var names []string
+	for i := 0; i < n; i++ {
+		action := fmt.Sprintf("action%c%c", 'a'+i/26%26, 'a'+i%26)
+		for clip := 1; clip <= 20; clip++ {
+			names = append(names, fmt.Sprintf("A_%s_%02d", action, clip))
+		}
+		names = append(names, fmt.Sprintf("A_%s_01_B", action), fmt.Sprintf("A_%s_05-07", action))
+	}
+	return names
+}
+
+func BenchmarkFetchAnimations(b *testing.B) {
+	names := benchmarkNames(50)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fetchAnimations(animationsOf(names...))
+	}
+}
+
+func TestOnlyAlternateA(t *testing.T) {
+	resolved := fetchAnimations(animationsOf("A_intro_01_A", "A_intro_02", "A_intro_03_A"))
+	set := indexByName(resolved)
+	assertNext(t, set, "A_intro_01_A", "A_intro_02")
+	assertNext(t, set, "A_intro_02", "A_intro_03_A")
+	assertPrevious(t, set, "A_intro_02", "A_intro_01_A")
+	assertPrevious(t, set, "A_intro_03_A", "A_intro_02")
+	assertAlternates(t, set, "A_intro_01_A")
+
+	if roots := Roots(resolved); !equalNames(roots, []string{"A_intro_01_A"}) {
+		t.Errorf("roots = %q, want A_intro_01_A", roots)
+	}
+	if got := canonical([]string{"A_intro_01_B", "A_intro_01_A"}); got != "A_intro_01_A" {
+		t.Errorf("canonical clip of A_intro_01_A and A_intro_01_B = %s", got)
+	}
+}
+
+func TestMixedAlternateSeparators(t *testing.T) {
+	set := resolve("A_intro_01_C", "A_intro_01B", "A_intro_01_A", "A_intro_02")
+	assertAlternates(t, set, "A_intro_01_A", "A_intro_01B", "A_intro_01_C")
+	assertAlternates(t, set, "A_intro_01B", "A_intro_01_A", "A_intro_01_C")
+	assertAlternates(t, set, "A_intro_01_C", "A_intro_01_A", "A_intro_01B")
+	assertNext(t, set, "A_intro_01_A", "A_intro_02")
+
+	set = resolve("A_intro_01", "A_intro_01_C", "A_intro_01B")
+	assertAlternates(t, set, "A_intro_01", "A_intro_01B", "A_intro_01_C")
+}
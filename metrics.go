@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds in seconds of the request latency histogram.
+var latencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// histogram counts observations in latencyBuckets.
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) observe(seconds float64) {
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// metrics records the requests of the query handlers and serves them in the Prometheus text format.
+type metrics struct {
+	mu         sync.Mutex
+	handlers   map[string]*histogram
+	animations func() int
+}
+
+func newMetrics(animations func() int) *metrics {
+	return &metrics{
+		handlers:   make(map[string]*histogram),
+		animations: animations,
+	}
+}
+
+// instrument records the count and latency of the requests served by handler under name.
+func (m *metrics) instrument(name string, handler http.HandlerFunc) http.HandlerFunc {
+	m.mu.Lock()
+	m.handlers[name] = &histogram{buckets: make([]uint64, len(latencyBuckets))}
+	m.mu.Unlock()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		handler(w, r)
+		elapsed := time.Since(start).Seconds()
+
+		m.mu.Lock()
+		m.handlers[name].observe(elapsed)
+		m.mu.Unlock()
+	}
+}
+
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.handlers))
+	for name := range m.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP clip_parse_requests_total Number of requests served by each query handler.")
+	fmt.Fprintln(w, "# TYPE clip_parse_requests_total counter")
+	for _, name := range names {
+		fmt.Fprintf(w, "clip_parse_requests_total{handler=%q} %d\n", name, m.handlers[name].count)
+	}
+
+	fmt.Fprintln(w, "# HELP clip_parse_request_duration_seconds Latency of the requests served by each query handler.")
+	fmt.Fprintln(w, "# TYPE clip_parse_request_duration_seconds histogram")
+	for _, name := range names {
+		h := m.handlers[name]
+		for i, bound := range latencyBuckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(w, "clip_parse_request_duration_seconds_bucket{handler=%q,le=%q} %d\n", name, le, h.buckets[i])
+		}
+		fmt.Fprintf(w, "clip_parse_request_duration_seconds_bucket{handler=%q,le=\"+Inf\"} %d\n", name, h.count)
+		fmt.Fprintf(w, "clip_parse_request_duration_seconds_sum{handler=%q} %g\n", name, h.sum)
+		fmt.Fprintf(w, "clip_parse_request_duration_seconds_count{handler=%q} %d\n", name, h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP clip_parse_animations Number of animations loaded.")
+	fmt.Fprintln(w, "# TYPE clip_parse_animations gauge")
+	fmt.Fprintf(w, "clip_parse_animations %d\n", m.animations())
+}
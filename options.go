@@ -0,0 +1,318 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sqlIdentifier matches the table names allowed in the SQL output, optionally qualified by a schema.
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// subcommand is a first argument standing for a set of flags, such as `validate` for -validate.
+type subcommand struct {
+	description string
+	apply       func()
+}
+
+// subcommands are the first arguments accepted before the flags. Without one the animations are built as with `build`.
+var subcommands = map[string]subcommand{
+	"build":     {"resolve the animations and print them in the -format, the default", nil},
+	"export":    {"same as build, usually given a -format", nil},
+	"validate":  {"build and report problems, like -validate", func() { opts.validate = true }},
+	"lint":      {"suggest corrected names, like -lint", func() { opts.lint = true }},
+	"inventory": {"print the actions and characters found, like -inventory", func() { opts.inventory = true }},
+	"query":     {"answer queries typed at a prompt, like -repl", func() { opts.repl = true }},
+	"serve": {"answer HTTP queries on -serve, :8080 by default", func() {
+		if opts.serve == "" {
+			opts.serve = ":8080"
+		}
+	}},
+}
+
+// usage prints the subcommands and the flags.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [command] [flags] [folders]\n\nCommands:\n", os.Args[0])
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-10s %s\n", name, subcommands[name].description)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// opts holds the command line flags.
+var opts struct {
+	// Naming convention
+	profile                string
+	profiles               string
+	charCase               string
+	numericPrefix          bool
+	base                   int
+	endMarker              string
+	charWidth              int
+	transitionSeparator    string
+	separator              string
+	bidirectionalSeparator string
+	alternateSeparator     string
+	actionPattern          string
+	pattern                string
+
+	// Input and output
+	manifest           string
+	input              string
+	trustColumns       bool
+	inputIndex         string
+	format             string
+	maxList            int
+	exportIndex        string
+	sqlAnimationsTable string
+	sqlEdgesTable      string
+	transitionWeight   float64
+	progress           bool
+	strict             bool
+	maxAnimations      int
+	followSymlinks     bool
+	reasons            bool
+	kindedNext         bool
+	weights            bool
+	clipIndex          bool
+	depth              bool
+	markUnparsed       bool
+	withPaths          bool
+	pathsAsRefs        bool
+	withIDs            bool
+	noCache            bool
+	clearCache         bool
+	since              time.Time
+	where              func(ParsedName) bool
+	subgraphFrom       string
+
+	// Modes replacing the regular output
+	explain               string
+	completeness          bool
+	inventory             bool
+	lint                  bool
+	pools                 bool
+	leaves                bool
+	repl                  bool
+	roots                 bool
+	graphStats            bool
+	sequence              string
+	longest               bool
+	into                  string
+	transitionOnlyEntries bool
+	playlist              string
+	seed                  int64
+	sequences             string
+	serve                 string
+	lazy                  bool
+
+	// Checks reported after the output
+	validate       bool
+	verifyFiles    bool
+	validateStrict bool
+	noBranch       bool
+	expect         string
+
+	// Passes over the resolved animations
+	altsAsNext          bool
+	collapseTransitions bool
+	excludeTransitions  bool
+	collapseAlternates  bool
+	firstOnly           bool
+}
+
+func parseFlags() error {
+	flag.StringVar(&opts.profile, "profile", "default", "name of the naming convention profile to parse names with")
+	flag.StringVar(&opts.profiles, "profiles", "", "JSON file defining additional naming convention profiles")
+	flag.StringVar(&opts.charCase, "char-case", "", "kind of character code, one of upper, lower or digit for numbered characters like A_intro_1_01, overriding the profile")
+	flag.BoolVar(&opts.numericPrefix, "numeric-prefix", false, "names start with a numeric ID such as 001_intro_01 instead of the prefix, overriding the profile")
+	flag.IntVar(&opts.base, "base", -1, "clip number sequences start at, 0 or 1, overriding the profile")
+	flag.StringVar(&opts.endMarker, "end-marker", "", "tag of the clips sequences end at, e.g. end for A_intro_99_end, overriding the profile")
+	flag.IntVar(&opts.charWidth, "char-width", 0, "maximum number of letters of a character code, overriding the profile")
+	flag.StringVar(&opts.transitionSeparator, "transition-separator", "", "separator between a clip and the clip it transitions to, overriding the profile")
+	flag.StringVar(&opts.separator, "separator", "", "separator between the tokens of a name, e.g. . for A.intro.01, overriding the profile")
+	flag.StringVar(&opts.bidirectionalSeparator, "bidirectional-separator", "", "separator of transitions playable in both directions, e.g. <-> for A_intro_01<->relax_01, overriding the profile")
+	flag.StringVar(&opts.alternateSeparator, "alternate-separator", "", "separator introducing an alternate letter, e.g. ~ for A_intro_X_01~B, overriding the profile")
+	flag.StringVar(&opts.actionPattern, "action-pattern", "", "expression matching an action, such as [A-Z][A-Za-z]+ for PascalCase names, overriding the profile")
+	flag.StringVar(&opts.pattern, "pattern", "", "regular expression parsing names instead of the one built from the profile, with at least the action and clip groups")
+	flag.StringVar(&opts.explain, "explain", "", "explain how this name parses and resolves against the loaded animations")
+	flag.BoolVar(&opts.completeness, "completeness", false, "print the clip numbers missing from the sequence of every character and action, exiting non-zero if any are")
+	flag.StringVar(&opts.format, "format", "json", "output format, one of "+strings.Join(formatNames(), ", "))
+	flag.IntVar(&opts.maxList, "max-list", 0, "in the table format, show at most this many names per list followed by how many more there are, 0 for all of them")
+	flag.StringVar(&opts.exportIndex, "export-index", "", "also write the resolved animations keyed by name to this JSON file, for -input-index")
+	flag.StringVar(&opts.sqlAnimationsTable, "sql-animations-table", "animations", "table the sql format inserts animations into")
+	flag.StringVar(&opts.sqlEdgesTable, "sql-edges-table", "edges", "table the sql format inserts relations into")
+	flag.Float64Var(&opts.transitionWeight, "transition-weight", 1, "weight of the edges into or out of a transition in the edgelist format")
+	flag.StringVar(&opts.manifest, "manifest", "", "read animation names from this file, one per line, instead of walking a folder")
+	flag.StringVar(&opts.input, "input", "", "read animations resolved by an earlier run with -format gob from this file instead of resolving them again, or the names of a .csv table")
+	flag.BoolVar(&opts.trustColumns, "trust-columns", false, "with a CSV -input, resolve clips from the action, char, clip and other group columns instead of matching their names again, for the rows whose names match the pattern with the same parts")
+	flag.StringVar(&opts.inputIndex, "input-index", "", "read animations resolved by an earlier run with -export-index from this file instead of resolving them again")
+	flag.BoolVar(&opts.inventory, "inventory", false, "print the distinct actions and characters found instead of the animations")
+	flag.BoolVar(&opts.lint, "lint", false, "suggest a corrected name for every name that doesn't match the naming pattern, with the rules that fired, instead of the animations")
+	flag.BoolVar(&opts.pools, "pools", false, "print the alternate families that aren't part of any sequence, such as idle variation pools")
+	flag.BoolVar(&opts.leaves, "leaves", false, "print the clips without a next animation, split into end marker clips and dead ends")
+	flag.BoolVar(&opts.progress, "progress", false, "periodically print the number of files discovered and animations resolved to stderr")
+	flag.BoolVar(&opts.strict, "strict", false, "fail on files and folders that can't be read and on duplicate animation names instead of skipping them with a warning, and on finding no animations")
+	flag.IntVar(&opts.maxAnimations, "max-animations", 0, "fail before resolving if more animations than this are found, 0 for no limit")
+	flag.BoolVar(&opts.followSymlinks, "follow-symlinks", false, "walk into symlinked folders, each real folder once")
+	flag.BoolVar(&opts.reasons, "reasons", false, "output next animations as {target, reason} objects telling why each was resolved")
+	flag.BoolVar(&opts.kindedNext, "kinded-next", false, "output next animations as {sequential, transitions, alternateAdvances} lists of the clips resolved each way")
+	flag.BoolVar(&opts.weights, "weights", false, "read the selection weights of alternates from *.weights.json sidecars and output alternate animations as {name, weight} objects")
+	flag.BoolVar(&opts.repl, "repl", false, "load the animations once and answer queries typed at a prompt")
+	flag.BoolVar(&opts.roots, "roots", false, "print the clips sequences start at, the ones at the base clip number")
+	flag.BoolVar(&opts.graphStats, "graph-stats", false, "print metrics of the resolved graph: connected components, isolated clips, next animation degrees and the longest sequence")
+	flag.StringVar(&opts.sequence, "sequence", "", "print the linear sequence of clips starting at this one")
+	flag.BoolVar(&opts.longest, "longest", false, "print the longest chain of next animations, failing if they form a cycle")
+	flag.StringVar(&opts.into, "into", "", "print the clips with this one as a next animation, through a transition or in sequence")
+	flag.BoolVar(&opts.transitionOnlyEntries, "transition-only-entries", false, "print the clips only entered through transitions")
+	flag.StringVar(&opts.playlist, "playlist", "", "print a playlist of clips starting at this one, picking one alternate and one next animation at every choice")
+	flag.Int64Var(&opts.seed, "seed", 0, "seed of the picks made by -playlist, the same seed giving the same playlist")
+	flag.StringVar(&opts.sequences, "sequences", "", "print the sequences starting at every clip matching this glob pattern, e.g. A_intro_*, leaving out the ones another sequence leads through")
+	flag.StringVar(&opts.serve, "serve", "", "load the animations once and answer HTTP queries on this address, e.g. :8080")
+	flag.BoolVar(&opts.lazy, "lazy", false, "with -serve or -repl, resolve each animation on its first query instead of all of them up front")
+	flag.BoolVar(&opts.validate, "validate", false, "report problems found in the resolved animations and exit non-zero if there are any")
+	flag.BoolVar(&opts.verifyFiles, "verify-files", false, "report next and previous animations that aren't loaded from the folder and exit non-zero if there are any")
+	flag.BoolVar(&opts.validateStrict, "validate-strict", false, "run every check, including -verify-files and -no-branch, print a JSON report of the issues by severity and exit non-zero on any error")
+	flag.BoolVar(&opts.noBranch, "no-branch", false, "report clips with more than one next animation and exit non-zero if there are any")
+	flag.StringVar(&opts.expect, "expect", "", "compare the resolved animations with the hand-verified ones of this json output file, reporting every clip that differs and exiting non-zero if any does")
+	flag.BoolVar(&opts.withIDs, "with-ids", false, "include a short stable ID of every animation and use it as the node identifier in graph formats")
+	flag.BoolVar(&opts.noCache, "no-cache", false, "resolve the animations even when an earlier run cached the resolution of the same set")
+	flag.BoolVar(&opts.clearCache, "clear-cache", false, "remove the cached resolutions of earlier runs and exit")
+	flag.Func("since", "only output the animations whose file changed after this RFC 3339 time or this long ago, e.g. 24h, still resolving against all of them", parseSince)
+	flag.Func("where", "only output the animations whose parsed name matches all of these conditions, e.g. action=intro,char=X,alternate=* where * means present, still resolving against all of them", parseWhereFlag)
+	flag.StringVar(&opts.subgraphFrom, "subgraph-from", "", "only output the animations reachable from this one through next and alternate animations, trimming relations to the others")
+	flag.BoolVar(&opts.clipIndex, "clip-index", false, "include the parsed clip number of every animation as ClipIndex")
+	flag.BoolVar(&opts.depth, "depth", false, "add the fewest next animations leading to each clip from a root, or -1 if none does")
+	flag.BoolVar(&opts.markUnparsed, "mark-unparsed", false, "set Unparsed on the animations whose name doesn't match the naming pattern")
+	flag.BoolVar(&opts.withPaths, "with-paths", false, "add the file each animation was read from")
+	flag.BoolVar(&opts.pathsAsRefs, "paths-as-refs", false, "output next, alternate and previous animations as the files they were read from, keeping the names of the ones without a file")
+	flag.BoolVar(&opts.altsAsNext, "alts-as-next", false, "use the alternates of clips without a successor as their next animations")
+	flag.BoolVar(&opts.collapseTransitions, "collapse-transitions", false, "skip over transition clips when listing next animations")
+	flag.BoolVar(&opts.excludeTransitions, "exclude-transitions", false, "leave transition clips out of the output, connecting their source to their target directly")
+	flag.BoolVar(&opts.collapseAlternates, "collapse-alternates", false, "merge every alternate family into its primary clip, listing the family in its Members")
+	flag.BoolVar(&opts.firstOnly, "first-only", false, "keep at most one next animation per clip")
+	flag.Usage = usage
+
+	args := os.Args[1:]
+	var command subcommand
+	if len(args) > 0 {
+		if c, ok := subcommands[args[0]]; ok {
+			command = c
+			args = args[1:]
+		}
+	}
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return err
+	}
+	if command.apply != nil {
+		command.apply()
+	}
+
+	if opts.lazy {
+		if opts.serve == "" && !opts.repl {
+			return fmt.Errorf("-lazy only applies to -serve and -repl")
+		}
+		if opts.input != "" || opts.inputIndex != "" || opts.altsAsNext || opts.collapseTransitions || opts.excludeTransitions || opts.collapseAlternates || opts.firstOnly {
+			return fmt.Errorf("-lazy can't be combined with -input, -input-index or passes over the resolved animations")
+		}
+	}
+	if opts.kindedNext && opts.reasons {
+		return fmt.Errorf("-kinded-next and -reasons are two ways of telling next animations apart, use one of them")
+	}
+	if opts.format == "yaml-anchors" && (opts.reasons || opts.kindedNext || opts.weights) {
+		return fmt.Errorf("-format yaml-anchors only writes lists of names and can't be combined with -reasons, -kinded-next or -weights")
+	}
+	if !opts.since.IsZero() && (opts.manifest != "" || opts.input != "" || opts.inputIndex != "") {
+		return fmt.Errorf("-since needs the modification times of a folder walk and can't be combined with -manifest, -input or -input-index")
+	}
+
+	if _, ok := formats[opts.format]; !ok {
+		return fmt.Errorf("unknown format %q", opts.format)
+	}
+	for _, table := range []string{opts.sqlAnimationsTable, opts.sqlEdgesTable} {
+		if !sqlIdentifier.MatchString(table) {
+			return fmt.Errorf("invalid SQL table name %q", table)
+		}
+	}
+
+	profiles, err := loadProfiles(opts.profiles)
+	if err != nil {
+		return err
+	}
+	p, ok := profiles[opts.profile]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", opts.profile)
+	}
+	if opts.charCase != "" {
+		p.CharCase = opts.charCase
+	}
+	if opts.endMarker != "" {
+		p.EndMarker = opts.endMarker
+	}
+	if opts.base >= 0 {
+		p.Base = opts.base
+	}
+	if opts.charWidth > 0 {
+		p.CharWidth = opts.charWidth
+	}
+	if opts.separator != "" {
+		p.Separator = opts.separator
+	}
+	if opts.transitionSeparator != "" {
+		p.TransitionSeparator = opts.transitionSeparator
+	}
+	if opts.numericPrefix {
+		p.NumericPrefix = true
+	}
+	if opts.alternateSeparator != "" {
+		p.AlternateSeparator = opts.alternateSeparator
+	}
+	if opts.bidirectionalSeparator != "" {
+		p.BidirectionalSeparator = opts.bidirectionalSeparator
+	}
+	if opts.actionPattern != "" {
+		p.ActionPattern = opts.actionPattern
+	}
+	if err := useProfile(p); err != nil {
+		return err
+	}
+	if opts.pattern != "" {
+		return usePattern(opts.pattern)
+	}
+	return nil
+}
+
+// parseSince sets opts.since from a duration before now, such as `24h`, or from an RFC 3339 time.
+func parseSince(value string) error {
+	if duration, err := time.ParseDuration(value); err == nil {
+		opts.since = time.Now().Add(-duration)
+		return nil
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("%q is neither a duration nor an RFC 3339 time", value)
+	}
+	opts.since = since
+	return nil
+}
+
+// parseWhereFlag sets opts.where from a -where expression.
+func parseWhereFlag(value string) error {
+	where, err := parseWhere(value)
+	if err != nil {
+		return err
+	}
+	opts.where = where
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Groups holds the submatches of a matched name, groups that didn't participate are empty.
+// Patterns without one of the groups simply leave it empty.
+type Groups []string
+
+// groupIndex is the submatch index of every group of re, or -1 for the groups it doesn't have.
+// It's computed once per pattern by indexGroups, so reading a group of a match is an array access.
+var groupIndex = indexGroups(re)
+
+// indexGroups returns the submatch index of every group of pattern, -1 for the groups it doesn't have.
+func indexGroups(pattern *regexp.Regexp) [groupCount]int {
+	var index [groupCount]int
+	for group, name := range groupNames {
+		index[group] = pattern.SubexpIndex(name)
+	}
+	return index
+}
+
+// MatchGroups matches name against the naming pattern of the active profile, returning nil if it doesn't match.
+// A match leaving one of the requiredGroups empty, which only a permissive custom pattern allows, is no match either,
+// so that malformed names such as `A__02` are never built from it.
+func MatchGroups(name string) Groups {
+	groups, _ := matchName(name)
+	return groups
+}
+
+// match returns the groups of the clip, the ones checked against a CSV table with -trust-columns or else MatchGroups of its name.
+func (clip *Animation) match() Groups {
+	if clip.groups != nil {
+		return clip.groups
+	}
+	return MatchGroups(clip.Name)
+}
+
+// matchName is MatchGroups returning why name doesn't match: a *NameError wrapping ErrUnparseableName,
+// ErrEmptyComponent along with the empty group, or strconv.ErrRange for a clip number too large, see clipNumber.
+func matchName(name string) (Groups, error) {
+	groups := Groups(re.FindStringSubmatch(name))
+	if groups == nil {
+		return nil, &NameError{Name: name, Err: ErrUnparseableName}
+	}
+	for _, group := range requiredGroups {
+		if groups.group(group) == "" {
+			return nil, &NameError{Name: name, Err: fmt.Errorf("%w: %s", ErrEmptyComponent, group)}
+		}
+	}
+	for _, group := range []Group{GroupClip, GroupNextClip} {
+		if _, err := clipNumber(groups.group(group)); err != nil {
+			return nil, &NameError{Name: name, Err: fmt.Errorf("%s: %w", group, err)}
+		}
+	}
+	return groups, nil
+}
+
+// group returns the value captured by group, or an empty string if the pattern has no such group.
+func (g Groups) group(group Group) string {
+	if i := groupIndex[group]; i >= 0 && i < len(g) {
+		return g[i]
+	}
+	return ""
+}
+
+func (g Groups) Action() string       { return g.group(GroupAction) }
+func (g Groups) Char() string         { return g.group(GroupChar) }
+func (g Groups) Clip() string         { return g.group(GroupClip) }
+func (g Groups) Alternate() string    { return g.group(GroupAlternate) }
+func (g Groups) Tag() string          { return g.group(GroupTag) }
+func (g Groups) TransitionTo() string { return g.group(GroupTransitionTo) }
+func (g Groups) NextName() string     { return g.group(GroupNextName) }
+func (g Groups) NextChar() string     { return g.group(GroupNextChar) }
+func (g Groups) NextClip() string     { return g.group(GroupNextClip) }
+
+func (g Groups) Bidirectional() string { return g.group(GroupBidirectional) }
+func (g Groups) ID() string            { return g.group(GroupID) }
+
+// ParsedName holds the parts of a parsed animation name, optional parts are empty when absent.
+// `A_intro_X_01_B` parses to Action "intro", Char "X", Clip "01" and Alternate "B", and `A_intro_01_loop` has the Tag "loop".
+type ParsedName struct {
+	// ID is the numeric prefix, such as `001` in `001_intro_01`, with profiles using one.
+	ID           string
+	Action       string
+	Char         string
+	Clip         string
+	Alternate    string
+	Tag          string
+	TransitionTo string
+	NextName     string
+	NextChar     string
+	NextClip     string
+	// Bidirectional is set for transitions written with the bidirectional separator, such as `A_intro_01<->relax_01`.
+	Bidirectional bool
+}
+
+// ParseName parses name with the active profile.
+// It returns a *NameError wrapping ErrUnparseableName if the name doesn't match,
+// ErrEmptyComponent if it matches without an action or a clip number, or strconv.ErrRange if its clip number is too large.
+func ParseName(name string) (ParsedName, error) {
+	result, err := matchName(name)
+	if err != nil {
+		return ParsedName{}, err
+	}
+
+	return ParsedName{
+		ID:           result.ID(),
+		Action:       result.Action(),
+		Char:         result.Char(),
+		Clip:         result.Clip(),
+		Alternate:    result.Alternate(),
+		Tag:          result.Tag(),
+		TransitionTo: result.TransitionTo(),
+		NextName:     result.NextName(),
+		NextChar:     result.NextChar(),
+		NextClip:     result.NextClip(),
+
+		Bidirectional: result.Bidirectional() != "",
+	}, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want ParsedName
+	}{
+		{"A_intro_01", ParsedName{Action: "intro", Clip: "01"}},
+		{"A_intro_01_loop", ParsedName{Action: "intro", Clip: "01", Tag: "loop"}},
+		{"A_intro_X_02_hold", ParsedName{Action: "intro", Char: "X", Clip: "02", Tag: "hold"}},
+		{"A_intro_X_01A", ParsedName{Action: "intro", Char: "X", Clip: "01", Alternate: "A"}},
+		{"A_intro_X_01B", ParsedName{Action: "intro", Char: "X", Clip: "01", Alternate: "B"}},
+		{"A_intro_01-02", ParsedName{Action: "intro", Clip: "01", TransitionTo: "02", NextClip: "02"}},
+		{"A_intro_X_01-combat_Y_01", ParsedName{Action: "intro", Char: "X", Clip: "01", TransitionTo: "combat_Y_01", NextName: "combat", NextChar: "Y", NextClip: "01"}},
+		{"A_intro_X_02-Y_03", ParsedName{Action: "intro", Char: "X", Clip: "02", TransitionTo: "Y_03", NextChar: "Y", NextClip: "03"}},
+	}
+	for _, test := range tests {
+		got, err := ParseName(test.name)
+		if err != nil {
+			t.Errorf("ParseName(%q): %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseName(%q) = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTaggedClips(t *testing.T) {
+	set := resolve("A_intro_01", "A_intro_01_loop", "A_intro_02", "A_intro_02_hold", "A_intro_03")
+	assertNext(t, set, "A_intro_01", "A_intro_02")
+	assertNext(t, set, "A_intro_01_loop", "A_intro_02")
+	assertNext(t, set, "A_intro_02_hold", "A_intro_03")
+	assertPrevious(t, set, "A_intro_02_hold", "A_intro_01")
+	assertPrevious(t, set, "A_intro_03", "A_intro_02")
+	if tag := set["A_intro_01_loop"].Tag; tag != "loop" {
+		t.Errorf("tag of A_intro_01_loop = %q, want loop", tag)
+	}
+}
+
+func TestCharAndAdjacentAlternate(t *testing.T) {
+	set := resolve("A_intro_X_01A", "A_intro_X_01B", "A_intro_X_02")
+	assertNext(t, set, "A_intro_X_01A", "A_intro_X_02")
+	assertNext(t, set, "A_intro_X_01B")
+	assertAlternates(t, set, "A_intro_X_01A", "A_intro_X_01B")
+	assertPrevious(t, set, "A_intro_X_02", "A_intro_X_01A")
+}
+
+func TestCrossCharacterTransitions(t *testing.T) {
+	set := resolve("A_intro_X_01", "A_intro_X_01-combat_Y_01", "A_combat_Y_01", "A_combat_X_01")
+	assertNext(t, set, "A_intro_X_01", "A_intro_X_01-combat_Y_01")
+	assertNext(t, set, "A_intro_X_01-combat_Y_01", "A_combat_Y_01")
+
+	set = resolve("A_intro_X_02", "A_intro_X_02-Y_03", "A_intro_X_03", "A_intro_Y_03", "A_intro_X_03-04", "A_intro_X_04")
+	assertNext(t, set, "A_intro_X_02", "A_intro_X_02-Y_03")
+	assertNext(t, set, "A_intro_X_02-Y_03", "A_intro_Y_03")
+	assertNext(t, set, "A_intro_X_03-04", "A_intro_X_04")
+	assertPrevious(t, set, "A_intro_X_03", "A_intro_X_02")
+}
+
+func TestLargeClipNumbers(t *testing.T) {
+	withProfile(t, defaultProfile)
+	if err := usePattern(`A_(?P<action>[a-z]+)_(?P<clip>\d+)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseName("A_x_999999999999"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("parsing a 12-digit clip number gave %v, want strconv.ErrRange", err)
+	}
+	if _, err := ParseName("A_x_2147483646"); err != nil {
+		t.Errorf("parsing the largest clip number: %v", err)
+	}
+	if _, err := ParseName("A_x_2147483647"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("parsing a clip number without a next one gave %v, want strconv.ErrRange", err)
+	}
+
+	set := resolve("A_x_999999999998", "A_x_999999999999", "A_x_01")
+	assertNext(t, set, "A_x_999999999998")
+	assertPrevious(t, set, "A_x_999999999999", "")
+}
+
+func TestPermissivePattern(t *testing.T) {
+	withProfile(t, defaultProfile)
+	if err := usePattern(`A_(?P<action>[a-z]*)_(?P<clip>\d*)`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"A__02", "A_intro_"} {
+		if _, err := ParseName(name); !errors.Is(err, ErrEmptyComponent) {
+			t.Errorf("ParseName(%q) gave %v, want ErrEmptyComponent", name, err)
+		}
+		if MatchGroups(name) != nil {
+			t.Errorf("MatchGroups(%q) matched", name)
+		}
+	}
+
+	set := resolve("A__01", "A__02", "A_intro_01", "A_intro_02")
+	assertNext(t, set, "A__01")
+	assertPrevious(t, set, "A__02", "")
+	assertNext(t, set, "A_intro_01", "A_intro_02")
+}
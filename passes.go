@@ -0,0 +1,325 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// indexByName maps every animation by its name, keeping the first animation of each name.
+func indexByName(animations []*Animation) map[string]*Animation {
+	byName := make(map[string]*Animation, len(animations))
+	for _, animation := range animations {
+		if animation == nil || byName[animation.Name] != nil {
+			continue
+		}
+		byName[animation.Name] = animation
+	}
+	return byName
+}
+
+// GroupByAction groups the animations by their parsed action, keeping their order within each group.
+// Names that don't parse are grouped under the empty action.
+func GroupByAction(animations []*Animation) map[string][]*Animation {
+	groups := make(map[string][]*Animation)
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		var action string
+		if parsed, err := ParseName(animation.Name); err == nil {
+			action = parsed.Action
+		}
+		groups[action] = append(groups[action], animation)
+	}
+	return groups
+}
+
+// isTransition reports whether the clip is a transition animation (e.g. `A_intro_01-02`).
+func (clip *Animation) isTransition() bool {
+	result := clip.match()
+	return result != nil && result.TransitionTo() != ""
+}
+
+// collapseTransitions replaces the next animations that are transition clips by the clips they lead to.
+// An example is `A_intro_01` -> `A_intro_01-02` -> `A_intro_02` becoming `A_intro_01` -> `A_intro_02`.
+// Transition clips keep their own next animations, and a transition that leads nowhere is kept as is.
+func collapseTransitions(animations []*Animation) {
+	byName := indexByName(animations)
+	for _, animation := range animations {
+		if animation == nil || animation.isTransition() {
+			continue
+		}
+
+		var next []string
+		reasons := make(map[string]Reason)
+		for _, edge := range animation.successors() {
+			targets := contentTargets(edge, byName, make(map[string]bool))
+			if len(targets) == 0 {
+				targets = []Successor{edge}
+			}
+			for _, target := range targets {
+				if !contains(next, target.Target) {
+					next = append(next, target.Target)
+					reasons[target.Target] = target.Reason
+				}
+			}
+		}
+		animation.NextAnimations = next
+		animation.reasons = reasons
+	}
+}
+
+// excludeTransitions collapses the transitions and then leaves the transition clips out,
+// returning a graph of the playable content clips only.
+func excludeTransitions(animations []*Animation) []*Animation {
+	collapseTransitions(animations)
+
+	var content []*Animation
+	for _, animation := range animations {
+		if animation == nil || animation.isTransition() {
+			continue
+		}
+		content = append(content, animation)
+	}
+	return content
+}
+
+// collapseAlternates merges every alternate family into its canonical clip. Relations to the other members are rewritten
+// to the primary, so `A_intro_01_B` becomes `A_intro_01`, and the family is kept in the Members of the primary.
+func collapseAlternates(animations []*Animation) []*Animation {
+	primaryOf := make(map[string]string)
+	for _, animation := range animations {
+		if animation == nil || len(animation.AlternateAnimations) == 0 || primaryOf[animation.Name] != "" {
+			continue
+		}
+		family := append([]string{animation.Name}, animation.AlternateAnimations...)
+		primary := canonical(family)
+		for _, name := range family {
+			primaryOf[name] = primary
+		}
+	}
+	rewrite := func(name string) string {
+		if primary := primaryOf[name]; primary != "" {
+			return primary
+		}
+		return name
+	}
+
+	byName := indexByName(animations)
+	var collapsed []*Animation
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		primary := rewrite(animation.Name)
+		if primary != animation.Name && byName[primary] != nil {
+			continue
+		}
+
+		members := []*Animation{animation}
+		if len(animation.AlternateAnimations) > 0 {
+			animation.Members = append([]string{animation.Name}, animation.AlternateAnimations...)
+			sort.Strings(animation.Members)
+			for _, name := range animation.AlternateAnimations {
+				if member := byName[name]; member != nil {
+					members = append(members, member)
+				}
+			}
+		}
+
+		var next []string
+		reasons := make(map[string]Reason)
+		for _, member := range members {
+			for _, edge := range member.successors() {
+				target := rewrite(edge.Target)
+				if target == animation.Name || contains(next, target) {
+					continue
+				}
+				next = append(next, target)
+				reasons[target] = edge.Reason
+			}
+		}
+		animation.NextAnimations = next
+		animation.reasons = reasons
+		animation.AlternateAnimations = nil
+		animation.PreviousAnimation = rewrite(animation.PreviousAnimation)
+		collapsed = append(collapsed, animation)
+	}
+	return collapsed
+}
+
+// canonical returns the member standing for an alternate family: the clip without an alternate letter,
+// else the one with the letter A, which is the implicit base when only `A_intro_01_A` and `A_intro_01_B` exist,
+// else the first member in name order.
+func canonical(family []string) string {
+	sorted := append([]string(nil), family...)
+	sort.Strings(sorted)
+	base := ""
+	for _, name := range sorted {
+		parsed, err := ParseName(name)
+		if err != nil {
+			continue
+		}
+		if parsed.Alternate == "" {
+			return name
+		}
+		if parsed.Alternate == "A" && base == "" {
+			base = name
+		}
+	}
+	if base == "" {
+		base = sorted[0]
+	}
+	return base
+}
+
+// contentTargets follows the transition clips starting at the edge target until it reaches clips that aren't transitions.
+// Each target keeps the reason of the last transition leading to it.
+// A transition already in visited ends the walk, so a cycle of transitions gives no targets instead of looping forever.
+func contentTargets(edge Successor, byName map[string]*Animation, visited map[string]bool) []Successor {
+	clip := byName[edge.Target]
+	if clip == nil || !clip.isTransition() {
+		return []Successor{edge}
+	}
+	if visited[edge.Target] {
+		return nil
+	}
+	visited[edge.Target] = true
+
+	var targets []Successor
+	for _, next := range clip.successors() {
+		targets = append(targets, contentTargets(next, byName, visited)...)
+	}
+	return targets
+}
+
+// firstOnly keeps at most one next animation per clip, for players that can only follow a single successor.
+// The kept one follows the precedence of getNextAnimation: a transition starting at the clip (`A_intro_01-02`)
+// comes before the sequential next clip (`A_intro_02`), and candidates of the same kind are taken in name order.
+func firstOnly(animations []*Animation) {
+	for _, animation := range animations {
+		if animation == nil || len(animation.NextAnimations) < 2 {
+			continue
+		}
+		animation.NextAnimations = animation.NextAnimations[:1]
+	}
+}
+
+// alternatesAsNext makes the alternates of a clip its next animations when no member of its alternate family
+// has a successor, such as the idle pool `A_idle_01` -> `A_idle_01_B`, `A_idle_01_C` without an `A_idle_02`.
+// `A_intro_01_B` keeps no next animation while `A_intro_01` advances to `A_intro_02`, as it's played in its place.
+// RandomNext is set on those clips so consumers know to pick one of them at random.
+func alternatesAsNext(animations []*Animation) {
+	advancing := make(map[string]bool)
+	for _, animation := range animations {
+		if animation == nil || len(animation.NextAnimations) == 0 {
+			continue
+		}
+		advancing[animation.Name] = true
+		for _, alternate := range animation.AlternateAnimations {
+			advancing[alternate] = true
+		}
+	}
+
+	for _, animation := range animations {
+		if animation == nil || advancing[animation.Name] || len(animation.AlternateAnimations) == 0 {
+			continue
+		}
+		for _, alternate := range animation.AlternateAnimations {
+			animation.addNext(alternate, ReasonAlternateAdvance)
+		}
+		animation.RandomNext = true
+	}
+}
+
+// changedSince returns the animations whose file changed after since.
+// They keep the relations resolved against the whole set, so their edges may point at animations left out.
+func changedSince(animations []*Animation, since time.Time) []*Animation {
+	var changed []*Animation
+	for _, animation := range animations {
+		if animation != nil && animation.modTime.After(since) {
+			changed = append(changed, animation)
+		}
+	}
+	return changed
+}
+
+// subgraphFrom returns copies of the animations reachable from root through next and alternate animations, root included,
+// with their relations to animations outside of the subgraph trimmed.
+// It returns a *NameError wrapping ErrUnknownAnimation if there's no animation called root.
+func subgraphFrom(root string, animations []*Animation) ([]*Animation, error) {
+	byName := indexByName(animations)
+	if byName[root] == nil {
+		return nil, &NameError{Name: root, Err: ErrUnknownAnimation}
+	}
+
+	reachable := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		clip := byName[queue[0]]
+		queue = queue[1:]
+		for _, name := range append(append([]string(nil), clip.NextAnimations...), clip.AlternateAnimations...) {
+			if !reachable[name] && byName[name] != nil {
+				reachable[name] = true
+				queue = append(queue, name)
+			}
+		}
+	}
+
+	inside := func(names []string) []string {
+		var kept []string
+		for _, name := range names {
+			if reachable[name] {
+				kept = append(kept, name)
+			}
+		}
+		return kept
+	}
+	var subgraph []*Animation
+	for _, animation := range animations {
+		if animation == nil || !reachable[animation.Name] {
+			continue
+		}
+		trimmed := *animation
+		trimmed.NextAnimations = inside(animation.NextAnimations)
+		trimmed.AlternateAnimations = inside(animation.AlternateAnimations)
+		if !reachable[trimmed.PreviousAnimation] {
+			trimmed.PreviousAnimation = ""
+		}
+		subgraph = append(subgraph, &trimmed)
+	}
+	return subgraph, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// patternPasses returns the flags of the passes over the resolved animations that depend on the naming pattern
+// or on the relations resolved with it, which resolving the animations again with another pattern would undo.
+func patternPasses() []string {
+	var passes []string
+	for _, pass := range []struct {
+		flag    string
+		enabled bool
+	}{
+		{"-alts-as-next", opts.altsAsNext},
+		{"-collapse-transitions", opts.collapseTransitions},
+		{"-exclude-transitions", opts.excludeTransitions},
+		{"-collapse-alternates", opts.collapseAlternates},
+		{"-first-only", opts.firstOnly},
+		{"-clip-index", opts.clipIndex},
+		{"-depth", opts.depth},
+		{"-mark-unparsed", opts.markUnparsed},
+	} {
+		if pass.enabled {
+			passes = append(passes, pass.flag)
+		}
+	}
+	return passes
+}
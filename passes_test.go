@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestAlternatesAsNext(t *testing.T) {
+	resolved := fetchAnimations(animationsOf("A_idle_01", "A_idle_01_B", "A_idle_01_C", "A_intro_01", "A_intro_01_B", "A_intro_02"))
+	alternatesAsNext(resolved)
+	set := indexByName(resolved)
+
+	assertNext(t, set, "A_idle_01", "A_idle_01_B", "A_idle_01_C")
+	assertNext(t, set, "A_idle_01_B", "A_idle_01", "A_idle_01_C")
+	if !set["A_idle_01"].RandomNext {
+		t.Error("A_idle_01 should pick its next animation at random")
+	}
+
+	assertNext(t, set, "A_intro_01", "A_intro_02")
+	assertNext(t, set, "A_intro_01_B")
+	if set["A_intro_01_B"].RandomNext {
+		t.Error("A_intro_01_B is played in place of A_intro_01, which advances")
+	}
+}
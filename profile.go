@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Profile bundles the parameters of a naming convention.
+// The default profile parses names like `A_intro_X_01_B-relax_01`.
+type Profile struct {
+	// Prefix is the literal every animation name starts with.
+	Prefix string `json:"prefix"`
+	// NumericPrefix replaces Prefix by a numeric ID such as `001` in `001_intro_01`, which is captured
+	// but plays no part in resolving: `001_intro_01` advances to `002_intro_02` just like to `007_intro_02`.
+	NumericPrefix bool `json:"numericPrefix"`
+	// Separator separates the tokens of a name.
+	Separator string `json:"separator"`
+	// TransitionSeparator separates a clip from the clip it transitions to.
+	TransitionSeparator string `json:"transitionSeparator"`
+	// BidirectionalSeparator separates the two ends of a transition playable in both directions, such as `<->`
+	// in `A_intro_01<->relax_01`, or is empty when names don't use one.
+	BidirectionalSeparator string `json:"bidirectionalSeparator"`
+	// AlternateSeparator introduces an alternate letter unambiguously, such as `~` in `A_intro_X_01~B`,
+	// or is empty when names don't use one. Alternates written without it are still recognized.
+	AlternateSeparator string `json:"alternateSeparator"`
+	// ActionPattern is the expression matching an action, such as `[A-Z][A-Za-z]+` for PascalCase names like `A_IntroScene_01`.
+	ActionPattern string `json:"actionPattern"`
+	// CharCase is the casing of the character letter, either "upper" or "lower",
+	// or "digit" for characters numbered like `A_intro_1_01`, where the clip number still has ClipWidth digits.
+	CharCase string `json:"charCase"`
+	// CharWidth is the maximum number of letters of a character code, such as 2 for `A_intro_AB_01`.
+	CharWidth int `json:"charWidth"`
+	// ClipWidth is the number of digits of a clip number.
+	ClipWidth int `json:"clipWidth"`
+	// EndMarker is the tag of the clips sequences end at, such as `end` for `A_intro_99_end`, or empty for none.
+	// End clips never get a next animation.
+	EndMarker string `json:"endMarker"`
+	// Base is the clip number sequences start at, 1 for `A_intro_01` unless a profile sets 0 for `A_intro_00`.
+	// Clips at the base have no previous animation.
+	Base int `json:"base"`
+}
+
+var defaultProfile = Profile{
+	Prefix:              "A",
+	Separator:           "_",
+	TransitionSeparator: "-",
+	ActionPattern:       "[a-z]+",
+	CharCase:            "upper",
+	CharWidth:           1,
+	ClipWidth:           2,
+	Base:                1,
+}
+
+// maxClipWidth is the most digits a clip number can have, so that it and the clip number after it fit in an int,
+// even on 32-bit platforms.
+const maxClipWidth = 9
+
+// profile is the naming convention currently used to parse and build names.
+var profile = defaultProfile
+
+// useProfile makes p the active profile and recompiles re and its groupIndex from it.
+func useProfile(p Profile) error {
+	compiled, err := p.compile()
+	if err != nil {
+		return err
+	}
+	profile = p
+	re = compiled
+	groupIndex = indexGroups(compiled)
+	return nil
+}
+
+// requiredGroups are the groups a custom pattern needs for its names to be resolved, the other groups being optional.
+var requiredGroups = []Group{GroupAction, GroupClip}
+
+// usePattern makes expression the naming pattern in place of the one compiled from the active profile.
+// The names of the next, previous and alternate animations are still built with the profile,
+// so the pattern should only change how names are parsed, such as accepting more actions.
+// The active pattern is kept if expression doesn't compile or lacks one of the requiredGroups.
+func usePattern(expression string) error {
+	compiled, err := regexp.Compile(expression)
+	if err != nil {
+		return fmt.Errorf("pattern: %w", err)
+	}
+	for _, group := range requiredGroups {
+		if compiled.SubexpIndex(group.String()) < 0 {
+			return fmt.Errorf("pattern %q has no %s group", expression, group)
+		}
+	}
+	re = compiled
+	groupIndex = indexGroups(compiled)
+	return nil
+}
+
+// loadProfiles returns the built-in profiles merged with the ones defined in the JSON file at path.
+// The file maps a profile name to its parameters, and omitted parameters default to the `default` profile:
+//
+//	{"studioA": {"prefix": "Anim", "clipWidth": 3}}
+func loadProfiles(path string) (map[string]Profile, error) {
+	profiles := map[string]Profile{"default": defaultProfile}
+	if path == "" {
+		return profiles, nil
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(bytes, &raw); err != nil {
+		return nil, fmt.Errorf("reading profiles from %s: %w", path, err)
+	}
+
+	for name, message := range raw {
+		p := defaultProfile
+		if err := json.Unmarshal(message, &p); err != nil {
+			return nil, fmt.Errorf("reading profile %q from %s: %w", name, path, err)
+		}
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// compile builds the regular expression for parsing names of this profile.
+// The named groups are the same as the ones documented on re.
+func (p Profile) compile() (*regexp.Regexp, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	var letter string
+	switch p.CharCase {
+	case "upper":
+		letter = "[A-Z]"
+	case "lower":
+		letter = "[a-z]"
+	case "digit":
+		letter = `\d`
+	default:
+		return nil, fmt.Errorf("profile char case must be upper, lower or digit, got %q", p.CharCase)
+	}
+	charClass, requiredChar := letter+"?", letter
+	if p.CharWidth > 1 {
+		charClass, requiredChar = letter+fmt.Sprintf("{0,%d}", p.CharWidth), letter+fmt.Sprintf("{1,%d}", p.CharWidth)
+	}
+
+	prefix := regexp.QuoteMeta(p.Prefix)
+	if p.NumericPrefix {
+		prefix = `(?P<id>\d+)`
+	}
+	sep := regexp.QuoteMeta(p.Separator)
+	optSep := optional(sep)
+	optTransition := optional(regexp.QuoteMeta(p.TransitionSeparator))
+	if p.BidirectionalSeparator != "" {
+		// Tried first, since it may contain the transition separator
+		optTransition = fmt.Sprintf("(?:(?P<bidirectional>%s)|%s)?", regexp.QuoteMeta(p.BidirectionalSeparator), regexp.QuoteMeta(p.TransitionSeparator))
+	}
+	// The separators around a character are optional, so `A_introX01`, `A_intro_X01` and `A_intro_X_01` are the same clip.
+	// Lowercase characters keep the separator after the action, which they couldn't be told apart from otherwise.
+	charJoin := optSep
+	if p.CharCase == "lower" {
+		charJoin = sep
+	}
+	charSlot := fmt.Sprintf("(?:%s(?P<char>%s)%s|%s)", charJoin, requiredChar, optSep, sep)
+	action := "(?:" + p.ActionPattern + ")"
+	if p.ActionPattern == defaultProfile.ActionPattern {
+		action = p.ActionPattern
+	}
+
+	return regexp.Compile(fmt.Sprintf(
+		`%[1]s%[2]s(?P<action>%[7]s)(?:%[8]s(?P<clip>\d{%[5]d}))%[9]s(?:(?P<tag>[a-z]+)$|(?P<alternate>[A-Z]?)?)%[6]s(?P<transitionTo>(?P<nextName>%[7]s)?%[4]s(?P<nextChar>%[3]s)%[4]s(?P<nextClip>\d{%[5]d}))?`,
+		prefix, sep, charClass, optSep, p.ClipWidth, optTransition, action, charSlot, p.alternateJoin(),
+	))
+}
+
+// validate checks the parameters can be combined into an unambiguous pattern.
+func (p Profile) validate() error {
+	if p.Prefix == "" || p.Separator == "" || p.TransitionSeparator == "" {
+		return fmt.Errorf("profile needs a prefix, a separator and a transition separator")
+	}
+	if p.ActionPattern == "" {
+		return fmt.Errorf("profile needs an action pattern")
+	}
+	action, err := regexp.Compile(p.ActionPattern)
+	if err != nil {
+		return fmt.Errorf("profile action pattern: %w", err)
+	}
+	if action.NumSubexp() > 0 {
+		return fmt.Errorf("profile action pattern %q can't contain capturing groups", p.ActionPattern)
+	}
+	if p.CharWidth < 1 {
+		return fmt.Errorf("profile char width must be at least 1, got %d", p.CharWidth)
+	}
+	if p.Base != 0 && p.Base != 1 {
+		return fmt.Errorf("profile base must be 0 or 1, got %d", p.Base)
+	}
+	if p.ClipWidth < 1 || p.ClipWidth > maxClipWidth {
+		return fmt.Errorf("profile clip width must be between 1 and %d, got %d", maxClipWidth, p.ClipWidth)
+	}
+
+	// Letters and digits would be read as part of an action, character or clip
+	for _, separator := range []string{p.Separator, p.TransitionSeparator} {
+		if strings.ContainsFunc(separator, isNameToken) {
+			return fmt.Errorf("separator %q can't contain letters or digits", separator)
+		}
+	}
+	if strings.Contains(p.Separator, p.TransitionSeparator) || strings.Contains(p.TransitionSeparator, p.Separator) {
+		return fmt.Errorf("transition separator %q collides with separator %q", p.TransitionSeparator, p.Separator)
+	}
+	if alternate := p.AlternateSeparator; alternate != "" {
+		if strings.ContainsFunc(alternate, isNameToken) {
+			return fmt.Errorf("separator %q can't contain letters or digits", alternate)
+		}
+		for _, other := range []string{p.Separator, p.TransitionSeparator, p.BidirectionalSeparator} {
+			if other != "" && (strings.Contains(alternate, other) || strings.Contains(other, alternate)) {
+				return fmt.Errorf("alternate separator %q collides with separator %q", alternate, other)
+			}
+		}
+	}
+	if bidirectional := p.BidirectionalSeparator; bidirectional != "" {
+		if strings.ContainsFunc(bidirectional, isNameToken) {
+			return fmt.Errorf("separator %q can't contain letters or digits", bidirectional)
+		}
+		if bidirectional == p.TransitionSeparator || strings.Contains(bidirectional, p.Separator) || strings.Contains(p.Separator, bidirectional) {
+			return fmt.Errorf("bidirectional separator %q collides with separator %q or transition separator %q", bidirectional, p.Separator, p.TransitionSeparator)
+		}
+	}
+	return nil
+}
+
+func isNameToken(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// optional makes the quoted expression optional, grouping it when it's longer than one character.
+func optional(quoted string) string {
+	if len(quoted) == 1 {
+		return quoted + "?"
+	}
+	return "(?:" + quoted + ")?"
+}
+
+// name builds the animation name for the given parts, leaving out the character when it's empty.
+// Example: `A_intro_01` or `A_intro_X_01`
+// With a numeric prefix the built names keep Prefix as a stand-in for the ID, which the expressions below match any ID for.
+func (p Profile) name(action, char, clip string) string {
+	if char == "" {
+		return p.Prefix + p.Separator + action + p.Separator + clip
+	}
+	return p.Prefix + p.Separator + action + p.Separator + char + p.Separator + clip
+}
+
+// clip formats the clip number padded to the clip width.
+func (p Profile) clip(number int) string {
+	return fmt.Sprintf("%0*d", p.ClipWidth, number)
+}
+
+// alternateJoin returns the expression between a clip number and its alternate letter or tag:
+// an optional separator, or the alternate separator when the profile has one.
+func (p Profile) alternateJoin() string {
+	optSep := optional(regexp.QuoteMeta(p.Separator))
+	if p.AlternateSeparator == "" {
+		return optSep
+	}
+	return fmt.Sprintf("(?:%s|%s)", regexp.QuoteMeta(p.AlternateSeparator), optSep)
+}
+
+// primary returns the expression matching name on its own or as its primary alternate (e.g. `A_intro_01_A`),
+// optionally followed by a tag (e.g. `A_intro_01_loop`).
+func (p Profile) primary(name string) string {
+	sep := regexp.QuoteMeta(p.Separator)
+	return fmt.Sprintf("^%s%sA?(?:%s[a-z]+)?$", p.quote(name), p.alternateJoin(), sep)
+}
+
+// variant returns the expression matching the alternate of name with the given letter (e.g. `A_intro_01_B`).
+func (p Profile) variant(name, letter string) string {
+	return fmt.Sprintf("^%s%s%s$", p.quote(name), p.alternateJoin(), regexp.QuoteMeta(letter))
+}
+
+// alternates returns the expression matching name and all of its alternates.
+func (p Profile) alternates(name string) string {
+	return fmt.Sprintf("^%s%s[A-Z]?$", p.quote(name), p.alternateJoin())
+}
+
+// transitions returns the expression matching the transition animations starting at name (e.g. `A_intro_01-02`),
+// including the bidirectional ones (e.g. `A_intro_01<->02`) when the profile has a bidirectional separator.
+func (p Profile) transitions(name string) string {
+	if p.BidirectionalSeparator != "" {
+		return fmt.Sprintf("^%s(?:%s|%s)", p.quote(name), regexp.QuoteMeta(p.BidirectionalSeparator), regexp.QuoteMeta(p.TransitionSeparator))
+	}
+	return fmt.Sprintf("^%s%s", p.quote(name), regexp.QuoteMeta(p.TransitionSeparator))
+}
+
+// bidirectionals returns the expression matching every name containing the bidirectional separator.
+func (p Profile) bidirectionals() string {
+	return fmt.Sprintf("^%s%s.*%s", p.quotedPrefix(), regexp.QuoteMeta(p.Separator), regexp.QuoteMeta(p.BidirectionalSeparator))
+}
+
+// quotedPrefix returns the expression matching the prefix of names, any number with a numeric prefix.
+func (p Profile) quotedPrefix() string {
+	if p.NumericPrefix {
+		return `\d+`
+	}
+	return regexp.QuoteMeta(p.Prefix)
+}
+
+// quote returns the expression matching the name built by name, or matching name literally if it wasn't built by name.
+// The prefix matches any ID with a numeric prefix, so `A_intro_02` finds `002_intro_02`,
+// and the separators around a character are optional like when parsing, so `A_intro_X_02` finds `A_introX02`.
+func (p Profile) quote(name string) string {
+	rest, ok := strings.CutPrefix(name, p.Prefix+p.Separator)
+	if !ok {
+		return regexp.QuoteMeta(name)
+	}
+	sep := regexp.QuoteMeta(p.Separator)
+	quoted := p.quotedPrefix() + sep
+	if tokens := strings.Split(rest, p.Separator); len(tokens) == 3 {
+		charJoin := optional(sep)
+		if p.CharCase == "lower" {
+			charJoin = sep
+		}
+		return quoted + regexp.QuoteMeta(tokens[0]) + charJoin + regexp.QuoteMeta(tokens[1]) + optional(sep) + regexp.QuoteMeta(tokens[2])
+	}
+	return quoted + regexp.QuoteMeta(rest)
+}
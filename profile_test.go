@@ -0,0 +1,273 @@
+package main
+
+import "testing"
+
+func TestCharWidth(t *testing.T) {
+	p := defaultProfile
+	p.CharWidth = 2
+	withProfile(t, p)
+
+	for name, want := range map[string]ParsedName{
+		"A_intro_AB_01":  {Action: "intro", Char: "AB", Clip: "01"},
+		"A_intro_X_01":   {Action: "intro", Char: "X", Clip: "01"},
+		"A_intro_X_01_B": {Action: "intro", Char: "X", Clip: "01", Alternate: "B"},
+	} {
+		got, err := ParseName(name)
+		if err != nil {
+			t.Errorf("ParseName(%q): %v", name, err)
+		} else if got != want {
+			t.Errorf("ParseName(%q) = %+v, want %+v", name, got, want)
+		}
+	}
+
+	set := resolve("A_intro_X_01", "A_intro_AB_01", "A_intro_AB_02", "A_intro_X_01_B", "A_intro_X_02")
+	assertNext(t, set, "A_intro_AB_01", "A_intro_AB_02")
+	assertPrevious(t, set, "A_intro_AB_02", "A_intro_AB_01")
+	assertNext(t, set, "A_intro_X_01", "A_intro_X_02")
+	assertPrevious(t, set, "A_intro_X_02", "A_intro_X_01")
+	assertAlternates(t, set, "A_intro_X_01", "A_intro_X_01_B")
+	assertAlternates(t, set, "A_intro_AB_01")
+	assertNext(t, set, "A_intro_X_01_B")
+}
+
+func TestActionPattern(t *testing.T) {
+	p := defaultProfile
+	p.ActionPattern = "[A-Z][A-Za-z]+"
+	withProfile(t, p)
+
+	set := resolve("A_IntroScene_01", "A_IntroScene_02", "A_IntroScene_02_B", "A_Relax_01")
+	assertNext(t, set, "A_IntroScene_01", "A_IntroScene_02")
+	assertPrevious(t, set, "A_IntroScene_02", "A_IntroScene_01")
+	assertAlternates(t, set, "A_IntroScene_02", "A_IntroScene_02_B")
+	assertNext(t, set, "A_Relax_01")
+
+	parsed, err := ParseName("A_IntroScene_02_B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Action != "IntroScene" || parsed.Alternate != "B" {
+		t.Errorf("ParseName(A_IntroScene_02_B) = %+v", parsed)
+	}
+}
+
+func TestDigitChars(t *testing.T) {
+	p := defaultProfile
+	p.CharCase = "digit"
+	withProfile(t, p)
+
+	for name, want := range map[string]ParsedName{
+		"A_intro_1_01": {Action: "intro", Char: "1", Clip: "01"},
+		"A_intro_01":   {Action: "intro", Clip: "01"},
+		"A_intro_1_02": {Action: "intro", Char: "1", Clip: "02"},
+	} {
+		got, err := ParseName(name)
+		if err != nil {
+			t.Errorf("ParseName(%q): %v", name, err)
+		} else if got != want {
+			t.Errorf("ParseName(%q) = %+v, want %+v", name, got, want)
+		}
+	}
+
+	set := resolve("A_intro_01", "A_intro_02", "A_intro_1_01", "A_intro_1_02")
+	assertNext(t, set, "A_intro_01", "A_intro_02")
+	assertNext(t, set, "A_intro_1_01", "A_intro_1_02")
+	assertPrevious(t, set, "A_intro_1_02", "A_intro_1_01")
+	assertAlternates(t, set, "A_intro_01")
+}
+
+func TestBase(t *testing.T) {
+	if defaultProfile.Base != 1 {
+		t.Errorf("default profile base = %d, want 1", defaultProfile.Base)
+	}
+
+	tests := []struct {
+		base  int
+		names []string
+		root  string
+	}{
+		{1, []string{"A_intro_01", "A_intro_02", "A_intro_03"}, "A_intro_01"},
+		{0, []string{"A_intro_00", "A_intro_01", "A_intro_02"}, "A_intro_00"},
+	}
+	for _, test := range tests {
+		p := defaultProfile
+		p.Base = test.base
+		withProfile(t, p)
+
+		resolved := fetchAnimations(animationsOf(test.names...))
+		set := indexByName(resolved)
+		assertPrevious(t, set, test.root, "")
+		assertPrevious(t, set, test.names[1], test.root)
+
+		if roots := Roots(resolved); !equalNames(roots, []string{test.root}) {
+			t.Errorf("roots with base %d = %q, want %s", test.base, roots, test.root)
+		}
+		if longest, err := LongestSequence(resolved); err != nil || len(longest) != len(test.names) {
+			t.Errorf("longest sequence with base %d = %q, %v", test.base, longest, err)
+		}
+		for _, entry := range checkCompleteness(resolved) {
+			if len(entry.Missing) > 0 {
+				t.Errorf("complete set with base %d is missing %v", test.base, entry.Missing)
+			}
+		}
+		setDepths(resolved)
+		for i, name := range test.names {
+			if depth := set[name].Depth; depth == nil || *depth != i {
+				t.Errorf("depth of %s with base %d = %v, want %d", name, test.base, depth, i)
+			}
+		}
+	}
+}
+
+func TestEndMarker(t *testing.T) {
+	p := defaultProfile
+	p.EndMarker = "end"
+	withProfile(t, p)
+
+	parsed, err := ParseName("A_intro_99_end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Clip != "99" || parsed.Tag != "end" {
+		t.Errorf("ParseName(A_intro_99_end) = %+v", parsed)
+	}
+
+	resolved := fetchAnimations(animationsOf("A_intro_98", "A_intro_99_end", "A_relax_01", "A_relax_02_end", "A_relax_03"))
+	set := indexByName(resolved)
+	assertNext(t, set, "A_intro_98", "A_intro_99_end")
+	assertNext(t, set, "A_intro_99_end")
+	assertPrevious(t, set, "A_intro_99_end", "A_intro_98")
+	assertNext(t, set, "A_relax_02_end")
+	assertPrevious(t, set, "A_relax_03", "A_relax_02_end")
+
+	leaves := FindLeaves(resolved)
+	if !equalNames(leaves.Ends, []string{"A_intro_99_end", "A_relax_02_end"}) {
+		t.Errorf("ends = %q", leaves.Ends)
+	}
+	if !contains(leaves.DeadEnds, "A_relax_03") || contains(leaves.DeadEnds, "A_intro_99_end") {
+		t.Errorf("dead ends = %q", leaves.DeadEnds)
+	}
+}
+
+func TestBidirectionalTransitions(t *testing.T) {
+	p := defaultProfile
+	p.BidirectionalSeparator = "<->"
+	withProfile(t, p)
+
+	set := resolve("A_intro_01", "A_intro_01<->relax_01", "A_intro_02", "A_relax_01", "A_relax_02")
+	assertNext(t, set, "A_intro_01", "A_intro_01<->relax_01")
+	assertNext(t, set, "A_intro_01<->relax_01", "A_relax_01")
+	assertNext(t, set, "A_relax_01", "A_relax_02", "A_intro_01")
+	assertNext(t, set, "A_relax_02")
+	if reason := set["A_relax_01"].reasons["A_intro_01"]; reason != ReasonTransitionReverse {
+		t.Errorf("A_relax_01 -> A_intro_01 resolved as %q, want %q", reason, ReasonTransitionReverse)
+	}
+
+	parsed, err := ParseName("A_intro_01<->relax_01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Bidirectional || parsed.NextName != "relax" || parsed.NextClip != "01" {
+		t.Errorf("ParseName(A_intro_01<->relax_01) = %+v", parsed)
+	}
+}
+
+func TestNumericPrefix(t *testing.T) {
+	p := defaultProfile
+	p.NumericPrefix = true
+	withProfile(t, p)
+
+	parsed, err := ParseName("001_intro_01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ParsedName{ID: "001", Action: "intro", Clip: "01"}); parsed != want {
+		t.Errorf("ParseName(001_intro_01) = %+v, want %+v", parsed, want)
+	}
+
+	set := resolve("001_intro_01", "002_intro_02", "007_intro_03", "003_intro_02_B", "004_relax_01")
+	assertNext(t, set, "001_intro_01", "002_intro_02")
+	assertNext(t, set, "002_intro_02", "007_intro_03")
+	assertPrevious(t, set, "007_intro_03", "002_intro_02")
+	assertPrevious(t, set, "002_intro_02", "001_intro_01")
+	assertAlternates(t, set, "002_intro_02", "003_intro_02_B")
+	assertNext(t, set, "004_relax_01")
+}
+
+func TestDotSeparator(t *testing.T) {
+	p := defaultProfile
+	p.Separator = "."
+	withProfile(t, p)
+
+	parsed, err := ParseName("A.intro.X.01.B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ParsedName{Action: "intro", Char: "X", Clip: "01", Alternate: "B"}); parsed != want {
+		t.Errorf("ParseName(A.intro.X.01.B) = %+v, want %+v", parsed, want)
+	}
+	if _, err := ParseName("A_intro_01"); err == nil {
+		t.Error("A_intro_01 shouldn't parse with the . separator")
+	}
+
+	set := resolve("A.intro.01", "A.intro.01.B", "A.intro.01-02", "A.intro.02", "A.intro.02-relax.01", "A.relax.01", "Axintroy01")
+	assertNext(t, set, "A.intro.01", "A.intro.01-02")
+	assertNext(t, set, "A.intro.01-02", "A.intro.02")
+	assertNext(t, set, "A.intro.02", "A.intro.02-relax.01")
+	assertNext(t, set, "A.intro.02-relax.01", "A.relax.01")
+	assertPrevious(t, set, "A.intro.02", "A.intro.01")
+	assertAlternates(t, set, "A.intro.01", "A.intro.01.B")
+	assertNext(t, set, "Axintroy01")
+}
+
+func TestCharSeparatorsOptional(t *testing.T) {
+	want := ParsedName{Action: "intro", Char: "X", Clip: "01"}
+	for _, name := range []string{"A_introX01", "A_intro_X01", "A_intro_X_01"} {
+		got, err := ParseName(name)
+		if err != nil {
+			t.Errorf("ParseName(%q): %v", name, err)
+		} else if got != want {
+			t.Errorf("ParseName(%q) = %+v, want %+v", name, got, want)
+		}
+	}
+
+	for _, first := range []string{"A_introX01", "A_intro_X01", "A_intro_X_01"} {
+		set := resolve(first, "A_intro_X_02", "A_intro_X01_B")
+		assertNext(t, set, first, "A_intro_X_02")
+		assertPrevious(t, set, "A_intro_X_02", first)
+		assertAlternates(t, set, first, "A_intro_X01_B")
+	}
+
+	// Lowercase characters keep the separator after the action
+	p := defaultProfile
+	p.CharCase = "lower"
+	withProfile(t, p)
+	if got, err := ParseName("A_intro_x01"); err != nil || got.Char != "x" {
+		t.Errorf("ParseName(A_intro_x01) = %+v, %v", got, err)
+	}
+}
+
+func TestAlternateSeparator(t *testing.T) {
+	p := defaultProfile
+	p.AlternateSeparator = "~"
+	withProfile(t, p)
+
+	for name, want := range map[string]ParsedName{
+		"A_intro_X_01~B": {Action: "intro", Char: "X", Clip: "01", Alternate: "B"},
+		"A_intro_01~B":   {Action: "intro", Clip: "01", Alternate: "B"},
+		"A_intro_X_01B":  {Action: "intro", Char: "X", Clip: "01", Alternate: "B"},
+		"A_intro_X_01":   {Action: "intro", Char: "X", Clip: "01"},
+	} {
+		got, err := ParseName(name)
+		if err != nil {
+			t.Errorf("ParseName(%q): %v", name, err)
+		} else if got != want {
+			t.Errorf("ParseName(%q) = %+v, want %+v", name, got, want)
+		}
+	}
+
+	set := resolve("A_intro_X_01", "A_intro_X_01~B", "A_intro_X_01_C", "A_intro_X_02", "A_intro_Y_01~B")
+	assertAlternates(t, set, "A_intro_X_01", "A_intro_X_01~B", "A_intro_X_01_C")
+	assertAlternates(t, set, "A_intro_Y_01~B")
+	assertNext(t, set, "A_intro_X_01", "A_intro_X_02")
+	assertNext(t, set, "A_intro_X_01~B")
+}
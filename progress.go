@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is how often the progress is printed.
+const progressInterval = time.Second
+
+// progress periodically prints how many items were counted to stderr.
+// A nil progress counts nothing, so callers don't need to check whether -progress is set.
+type progress struct {
+	label    string
+	count    atomic.Int64
+	done     chan struct{}
+	finished chan struct{}
+}
+
+// startProgress starts printing the count under label if -progress is set.
+func startProgress(label string) *progress {
+	if !opts.progress {
+		return nil
+	}
+	p := &progress{
+		label:    label,
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *progress) run() {
+	defer close(p.finished)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.print()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *progress) print() {
+	fmt.Fprintf(os.Stderr, "%s: %d\n", p.label, p.count.Load())
+}
+
+func (p *progress) add() {
+	if p == nil {
+		return
+	}
+	p.count.Add(1)
+}
+
+// stop stops the ticker and prints the final count.
+func (p *progress) stop() {
+	if p == nil {
+		return
+	}
+	close(p.done)
+	<-p.finished
+	p.print()
+}
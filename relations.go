@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RelationPattern returns the expression matching the names related to clip, or "" if it has none of that kind.
+// A "blend" kind could return `^A_intro_01\+blend_` for `A_intro_01`, relating it to `A_intro_01+blend_01`.
+type RelationPattern func(clip *Animation) string
+
+// relationPatterns are the custom relation kinds registered with RegisterRelation.
+var relationPatterns = make(map[string]RelationPattern)
+
+// RegisterRelation adds a custom relation kind, resolved by fetchAnimations into the Relations of every animation.
+// The related animations are the ones whose name matches the expression returned by pattern, other than the clip itself.
+// It panics if kind is empty, is one of the built-in kinds or is registered twice, like the other registries of Go.
+func RegisterRelation(kind string, pattern RelationPattern) {
+	switch EdgeKind(kind) {
+	case "", NextEdge, AlternateEdge, PreviousEdge:
+		panic(fmt.Sprintf("can't register relation kind %q", kind))
+	}
+	if _, ok := relationPatterns[kind]; ok {
+		panic(fmt.Sprintf("relation kind %q registered twice", kind))
+	}
+	relationPatterns[kind] = pattern
+}
+
+// getRelations resolves the custom relations of the clip, in the order of the kind names.
+func (clip *Animation) getRelations(index Index) {
+	kinds := make([]string, 0, len(relationPatterns))
+	for kind := range relationPatterns {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		expression := relationPatterns[kind](clip)
+		if expression == "" {
+			continue
+		}
+		for _, related := range filterAnimations(expression, index) {
+			if related.Name == clip.Name {
+				continue
+			}
+			if clip.Relations == nil {
+				clip.Relations = make(map[string][]string)
+			}
+			clip.Relations[kind] = append(clip.Relations[kind], related.Name)
+		}
+	}
+}
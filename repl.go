@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const replHelp = `commands:
+  next <name>        next animations of a clip
+  alt <name>         alternate animations of a clip
+  prev <name>        previous animation of a clip
+  path <from> <to>   shortest chain of next animations between two clips
+  :pattern <regex>   parse names with this pattern and resolve again
+  help               show this help
+  quit               leave`
+
+// commandArgs is the number of arguments each query command takes.
+var commandArgs = map[string]int{
+	"next": 1,
+	"alt":  1,
+	"prev": 1,
+	"path": 2,
+}
+
+// runREPL reads queries from r and prints their results to w until r ends or quit is typed.
+func runREPL(set *AnimationSet, r io.Reader, w io.Writer) {
+	scanner := bufio.NewScanner(r)
+	fmt.Fprint(w, "> ")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == ":pattern" {
+			// The pattern is the rest of the line, spaces included
+			fmt.Fprintln(w, setPattern(set, strings.TrimSpace(strings.TrimPrefix(line, ":pattern"))))
+		} else if len(fields) > 0 {
+			if fields[0] == "quit" || fields[0] == "exit" {
+				return
+			}
+			fmt.Fprintln(w, query(set, fields[0], fields[1:]))
+		}
+		fmt.Fprint(w, "> ")
+	}
+}
+
+// setPattern switches the set to the naming pattern expression and returns the text to print.
+func setPattern(set *AnimationSet, expression string) string {
+	if expression == "" {
+		return fmt.Sprintf("pattern is %s", re)
+	}
+	if err := set.SetPattern(expression); err != nil {
+		return err.Error() + ", keeping the previous pattern"
+	}
+	return fmt.Sprintf("resolved %d animations with the new pattern", len(set.Animations))
+}
+
+// query runs a single command against the set and returns the text to print.
+func query(set *AnimationSet, command string, args []string) string {
+	if command == "help" {
+		return replHelp
+	}
+
+	want, ok := commandArgs[command]
+	if !ok {
+		return fmt.Sprintf("unknown command %s, type help for usage", command)
+	}
+	if len(args) != want {
+		return fmt.Sprintf("%s takes %d argument(s), type help for usage", command, want)
+	}
+
+	clip, err := set.Lookup(args[0])
+	if err != nil {
+		return err.Error()
+	}
+
+	switch command {
+	case "next":
+		return list(clip.NextAnimations)
+	case "alt":
+		return list(clip.AlternateAnimations)
+	case "prev":
+		if clip.PreviousAnimation == "" {
+			return "none"
+		}
+		return clip.PreviousAnimation
+	case "path":
+		path := set.Path(args[0], args[1])
+		if path == nil {
+			return fmt.Sprintf("no path from %s to %s", args[0], args[1])
+		}
+		return strings.Join(path, " -> ")
+	}
+	return ""
+}
+
+func list(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "\n")
+}
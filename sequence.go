@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"sort"
+)
+
+// Sequence follows the single next animation of every clip from start, returning the chain starting with start.
+// It stops at a clip without a next animation, at a clip that branches into several next animations
+// and before revisiting a clip, so `A_intro_01` gives `[A_intro_01, A_intro_02, A_intro_03]`.
+// It returns a *NameError wrapping ErrUnknownAnimation if start isn't in the set.
+func Sequence(start string, set *AnimationSet) ([]string, error) {
+	clip, err := set.Lookup(start)
+	if err != nil {
+		return nil, err
+	}
+
+	sequence := []string{start}
+	visited := map[string]bool{start: true}
+	for len(clip.NextAnimations) == 1 {
+		next := clip.NextAnimations[0]
+		if visited[next] {
+			break
+		}
+		clip = set.Get(next)
+		if clip == nil {
+			break
+		}
+		visited[next] = true
+		sequence = append(sequence, next)
+	}
+	return sequence, nil
+}
+
+// Playlist walks the sequence from start like Sequence, but picks one of the clips wherever there's a choice:
+// one member of the alternate family of every clip reached, which is the clip played, and one of its next animations.
+// Picks are made with rng, so the same seed gives the same playlist. It stops before revisiting a clip
+// and returns a *NameError wrapping ErrUnknownAnimation if start isn't in the set.
+func Playlist(start string, set *AnimationSet, rng *rand.Rand) ([]string, error) {
+	clip, err := set.Lookup(start)
+	if err != nil {
+		return nil, err
+	}
+
+	var playlist []string
+	visited := map[string]bool{start: true}
+	for {
+		family := append([]string{clip.Name}, clip.AlternateAnimations...)
+		sort.Strings(family)
+		playlist = append(playlist, family[rng.Intn(len(family))])
+
+		// Alternates other than the first one (A) don't advance, so the clip reached picks the next one
+		if len(clip.NextAnimations) == 0 {
+			break
+		}
+		next := clip.NextAnimations[rng.Intn(len(clip.NextAnimations))]
+		if visited[next] {
+			break
+		}
+		if clip = set.Get(next); clip == nil {
+			break
+		}
+		visited[next] = true
+	}
+	return playlist, nil
+}
+
+// Sequences returns the sequence of every clip whose name matches the glob pattern, such as `A_intro_*`.
+// Sequences that are the tail of another one are left out, so `A_intro_02` isn't listed on its own
+// when `A_intro_01` already leads through it. It returns an error if the pattern is malformed.
+func Sequences(pattern string, set *AnimationSet) ([][]string, error) {
+	var sequences [][]string
+	covered := make(map[string]int)
+	for _, clip := range set.Animations {
+		if clip == nil {
+			continue
+		}
+		matched, err := path.Match(pattern, clip.Name)
+		if err != nil {
+			return nil, fmt.Errorf("sequence pattern %q: %w", pattern, err)
+		}
+		if !matched || covered[clip.Name] > 0 {
+			continue
+		}
+
+		sequence, err := Sequence(clip.Name, set)
+		if err != nil {
+			return nil, err
+		}
+
+		// Drop the earlier sequences this one leads into
+		kept := sequences[:0]
+		for _, other := range sequences {
+			if contains(sequence[1:], other[0]) {
+				for _, name := range other {
+					covered[name]--
+				}
+				continue
+			}
+			kept = append(kept, other)
+		}
+		sequences = append(kept, sequence)
+		for _, name := range sequence {
+			covered[name]++
+		}
+	}
+	return sequences, nil
+}
+
+// LongestSequence returns the longest chain of next animations in the animations, such as the worst-case length
+// of a cutscene. Among chains of the same length the first one found in the order of the animations is returned.
+// It returns a *NameError wrapping ErrNextCycle on a clip of a cycle, since a cycle has no longest chain.
+func LongestSequence(animations []*Animation) ([]string, error) {
+	byName := indexByName(animations)
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	// longest is the longest chain starting at each clip
+	longest := make(map[string][]string)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return &NameError{Name: name, Err: ErrNextCycle}
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		var tail []string
+		for _, next := range byName[name].NextAnimations {
+			if byName[next] == nil {
+				continue
+			}
+			if err := visit(next); err != nil {
+				return err
+			}
+			if len(longest[next]) > len(tail) {
+				tail = longest[next]
+			}
+		}
+		longest[name] = append([]string{name}, tail...)
+		state[name] = done
+		return nil
+	}
+
+	sequence := []string{}
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		if err := visit(animation.Name); err != nil {
+			return nil, err
+		}
+		if len(longest[animation.Name]) > len(sequence) {
+			sequence = longest[animation.Name]
+		}
+	}
+	return sequence, nil
+}
+
+// Roots returns the clips sequences start at: the parsed clips at the profile base that aren't transitions,
+// such as `A_intro_01` and `A_relax_01` with -base 1. Alternate families are listed once, by their canonical clip,
+// so `A_intro_01_A` is the root when there's no `A_intro_01`.
+func Roots(animations []*Animation) []string {
+	var roots []string
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		parsed, err := ParseName(animation.Name)
+		if err != nil || parsed.TransitionTo != "" || atoi(parsed.Clip) != profile.Base {
+			continue
+		}
+		if len(animation.AlternateAnimations) > 0 && canonical(append([]string{animation.Name}, animation.AlternateAnimations...)) != animation.Name {
+			continue
+		}
+		roots = append(roots, animation.Name)
+	}
+	return roots
+}
+
+// Leaves are the clips without a next animation, split by whether the sequence is meant to end there.
+type Leaves struct {
+	// Ends are the clips tagged with the profile end marker, such as `A_intro_99_end`.
+	Ends []string `json:"ends"`
+	// DeadEnds are the other clips without a next animation, which may be missing their successor.
+	DeadEnds []string `json:"deadEnds"`
+}
+
+// FindLeaves returns the clips sequences stop at. Non-primary alternates are left out, since they never advance.
+func FindLeaves(animations []*Animation) Leaves {
+	leaves := Leaves{Ends: []string{}, DeadEnds: []string{}}
+	for _, animation := range animations {
+		if animation == nil || len(animation.NextAnimations) > 0 {
+			continue
+		}
+		parsed, err := ParseName(animation.Name)
+		if err != nil || parsed.Alternate != "" && parsed.Alternate != "A" {
+			continue
+		}
+		if profile.EndMarker != "" && parsed.Tag == profile.EndMarker {
+			leaves.Ends = append(leaves.Ends, animation.Name)
+		} else {
+			leaves.DeadEnds = append(leaves.DeadEnds, animation.Name)
+		}
+	}
+	return leaves
+}
+
+// StandalonePools returns the alternate families that aren't part of any sequence: clips with alternates where
+// no member has a next or a previous animation, such as the idle pool `A_idle_01`, `A_idle_01_B` and `A_idle_01_C`.
+// Each family is sorted by name and listed once.
+func StandalonePools(animations []*Animation) [][]string {
+	byName := indexByName(animations)
+	seen := make(map[string]bool)
+
+	pools := [][]string{}
+	for _, animation := range animations {
+		if animation == nil || len(animation.AlternateAnimations) == 0 || seen[animation.Name] {
+			continue
+		}
+
+		family := append([]string{animation.Name}, animation.AlternateAnimations...)
+		sort.Strings(family)
+		standalone := true
+		for _, name := range family {
+			seen[name] = true
+			if member := byName[name]; member != nil && (len(member.NextAnimations) > 0 || member.PreviousAnimation != "") {
+				standalone = false
+			}
+		}
+		if standalone {
+			pools = append(pools, family)
+		}
+	}
+	return pools
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// newServer returns the HTTP handler answering queries against the set:
+//
+//	GET /animations              every animation
+//	GET /animation?name=<name>   a single animation
+//	GET /path?from=<a>&to=<b>    shortest chain of next animations between two clips
+//	GET /metrics                 request counts and latencies in the Prometheus text format
+//	POST /pattern                parse names with the pattern in the body and resolve again
+//
+// Requests are served one at a time, since a lazy set resolves animations while answering and /pattern replaces them all.
+func newServer(set *AnimationSet) http.Handler {
+	m := newMetrics(func() int { return len(set.Animations) })
+	var mu sync.Mutex
+	handle := func(name string, handler http.HandlerFunc) http.HandlerFunc {
+		return m.instrument(name, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			handler(w, r)
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/animations", handle("animations", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, set.Resolved())
+	}))
+	mux.HandleFunc("/animation", handle("animation", func(w http.ResponseWriter, r *http.Request) {
+		clip, err := set.Lookup(r.URL.Query().Get("name"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSONResponse(w, clip)
+	}))
+	mux.HandleFunc("/path", handle("path", func(w http.ResponseWriter, r *http.Request) {
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		for _, name := range []string{from, to} {
+			if _, err := set.Lookup(name); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+		}
+		path := set.Path(from, to)
+		if path == nil {
+			path = []string{}
+		}
+		writeJSONResponse(w, path)
+	}))
+	mux.HandleFunc("/pattern", handle("pattern", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "/pattern only accepts POST", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := set.SetPattern(strings.TrimSpace(string(body))); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSONResponse(w, map[string]any{"pattern": re.String(), "animations": len(set.Animations)})
+	}))
+	mux.Handle("/metrics", m)
+	return mux
+}
+
+func writeJSONResponse(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AnimationSet is a resolved collection of animations that can be queried by name.
+type AnimationSet struct {
+	Animations []*Animation
+	byName     map[string]*Animation
+	// graph is built on the first call to Graph and cleared whenever relations are resolved again.
+	graph *Graph
+	// lazy sets resolve each animation on its first lookup instead of up front, recording it in resolved.
+	lazy     bool
+	resolved map[string]bool
+	// passes are the flags of the passes applied over the resolved animations, which resolving them again would undo.
+	passes []string
+}
+
+// NewAnimationSet resolves the animations and indexes them by name.
+func NewAnimationSet(animations []*Animation) *AnimationSet {
+	return indexSet(fetchAnimations(animations))
+}
+
+// NewLazyAnimationSet indexes the animations without resolving them.
+// Each animation is resolved the first time it's looked up, with Get, Lookup, Next, Alternates or Previous,
+// which gives the same relations as NewAnimationSet while only paying for the clips that are queried.
+func NewLazyAnimationSet(animations []*Animation) *AnimationSet {
+	set := indexSet(sortAnimations(animations))
+	set.lazy = true
+	set.resolved = make(map[string]bool)
+	return set
+}
+
+// indexSet indexes already resolved animations.
+func indexSet(animations []*Animation) *AnimationSet {
+	return &AnimationSet{
+		Animations: animations,
+		byName:     indexByName(animations),
+	}
+}
+
+// Get returns the animation called name, or nil if there is none.
+func (set *AnimationSet) Get(name string) *Animation {
+	clip := set.byName[name]
+	if clip != nil && set.lazy && !set.resolved[name] {
+		set.resolved[name] = true
+		set.resolve([]*Animation{clip})
+	}
+	return clip
+}
+
+// Next returns the next animations of the animation called name, or nil if there is none.
+func (set *AnimationSet) Next(name string) []string {
+	if clip := set.Get(name); clip != nil {
+		return clip.NextAnimations
+	}
+	return nil
+}
+
+// Alternates returns the alternates of the animation called name, or nil if there is none.
+func (set *AnimationSet) Alternates(name string) []string {
+	if clip := set.Get(name); clip != nil {
+		return clip.AlternateAnimations
+	}
+	return nil
+}
+
+// Previous returns the previous animation of the animation called name, or "" if there is none.
+func (set *AnimationSet) Previous(name string) string {
+	if clip := set.Get(name); clip != nil {
+		return clip.PreviousAnimation
+	}
+	return ""
+}
+
+// Into returns the animations with the animation called name as one of their next animations,
+// through a transition (`A_intro_01-02` -> `A_intro_02`) or in sequence (`A_intro_01` -> `A_intro_02`).
+// It returns a *NameError wrapping ErrUnknownAnimation if there's no animation called name.
+func (set *AnimationSet) Into(name string) ([]string, error) {
+	if _, err := set.Lookup(name); err != nil {
+		return nil, err
+	}
+	sources := []string{}
+	for _, edge := range set.Graph().In(name) {
+		if edge.Kind == NextEdge {
+			sources = append(sources, edge.From)
+		}
+	}
+	return sources, nil
+}
+
+// TransitionOnlyEntries returns the clips that are only entered through transitions: the clips other than transitions
+// with next animations leading into them, all of them from transition animations, such as `A_relax_01`
+// entered from `A_intro_02-relax_01` but from no `A_relax_00`.
+func (set *AnimationSet) TransitionOnlyEntries() []string {
+	graph := set.Graph()
+	entries := []string{}
+	for _, animation := range set.Animations {
+		if animation == nil || animation.isTransition() {
+			continue
+		}
+		inbound, fromTransitions := 0, 0
+		for _, edge := range graph.In(animation.Name) {
+			if edge.Kind != NextEdge {
+				continue
+			}
+			inbound++
+			if source := set.Get(edge.From); source != nil && source.isTransition() {
+				fromTransitions++
+			}
+		}
+		if inbound > 0 && inbound == fromTransitions {
+			entries = append(entries, animation.Name)
+		}
+	}
+	return entries
+}
+
+// Resolved returns all the animations of the set, resolving the ones a lazy set hasn't yet.
+func (set *AnimationSet) Resolved() []*Animation {
+	for _, animation := range set.Animations {
+		if animation != nil {
+			set.Get(animation.Name)
+		}
+	}
+	return set.Animations
+}
+
+// Graph returns the relations of the set in both directions.
+func (set *AnimationSet) Graph() *Graph {
+	if set.graph == nil {
+		set.graph = NewGraph(set.Resolved())
+	}
+	return set.graph
+}
+
+// Path returns the shortest chain of next animations leading from `from` to `to`, both included.
+// It returns nil if `to` can't be reached.
+func (set *AnimationSet) Path(from, to string) []string {
+	if set.Get(from) == nil || set.Get(to) == nil {
+		return nil
+	}
+
+	cameFrom := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == to {
+			var path []string
+			for ; name != ""; name = cameFrom[name] {
+				path = append([]string{name}, path...)
+			}
+			return path
+		}
+
+		clip := set.Get(name)
+		if clip == nil {
+			continue
+		}
+		for _, next := range clip.NextAnimations {
+			if _, ok := cameFrom[next]; ok {
+				continue
+			}
+			cameFrom[next] = name
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the animation called name.
+// It returns a *NameError wrapping ErrUnknownAnimation if there is none.
+func (set *AnimationSet) Lookup(name string) (*Animation, error) {
+	clip := set.Get(name)
+	if clip == nil {
+		return nil, &NameError{Name: name, Err: ErrUnknownAnimation}
+	}
+	return clip, nil
+}
+
+// TransitionTarget returns the animation the transition animation called name leads to.
+// The returned error wraps ErrUnknownAnimation, ErrUnparseableName, ErrNotTransition or ErrMissingTransitionTarget.
+func (set *AnimationSet) TransitionTarget(name string) (string, error) {
+	clip, err := set.Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := ParseName(name)
+	if err != nil {
+		return "", err
+	}
+	if parsed.TransitionTo == "" {
+		return "", &NameError{Name: name, Err: ErrNotTransition}
+	}
+	if len(clip.NextAnimations) == 0 {
+		return "", &NameError{Name: name, Err: ErrMissingTransitionTarget}
+	}
+	return clip.NextAnimations[0], nil
+}
+
+// AddAndResolve adds an animation called name and resolves it, recomputing only the relations of its neighbors
+// instead of the whole set. The result is the same as resolving the whole set again with fetchAnimations.
+// It returns a *NameError wrapping ErrDuplicateAnimation if the set already has an animation with that name.
+func (set *AnimationSet) AddAndResolve(name string) error {
+	if set.Get(name) != nil {
+		return &NameError{Name: name, Err: ErrDuplicateAnimation}
+	}
+
+	clip := &Animation{Name: name}
+	i := sort.Search(len(set.Animations), func(i int) bool {
+		return set.Animations[i] == nil || set.Animations[i].Name >= name
+	})
+	set.Animations = append(set.Animations, nil)
+	copy(set.Animations[i+1:], set.Animations[i:])
+	set.Animations[i] = clip
+	set.byName[name] = clip
+
+	set.resolve(append(set.neighbors(name), clip))
+	return nil
+}
+
+// RemoveAndResolve removes the animation called name, recomputing only the relations of its neighbors.
+// It returns a *NameError wrapping ErrUnknownAnimation if there is none.
+func (set *AnimationSet) RemoveAndResolve(name string) error {
+	if _, err := set.Lookup(name); err != nil {
+		return err
+	}
+
+	neighbors := set.neighbors(name)
+	for i, animation := range set.Animations {
+		if animation != nil && animation.Name == name {
+			set.Animations = append(set.Animations[:i], set.Animations[i+1:]...)
+			break
+		}
+	}
+	delete(set.byName, name)
+
+	set.resolve(neighbors)
+	return nil
+}
+
+// neighbors returns the animations whose relations can change when an animation called name is added or removed:
+// the clips of the same action and character up to one clip number away, the transitions leading to it or into its action,
+// the clips at either end of a bidirectional transition, and the animations already referencing it.
+func (set *AnimationSet) neighbors(name string) []*Animation {
+	parsed, err := ParseName(name)
+
+	// Bidirectional transitions starting at the clip are played backwards to it from the clip they end at
+	var reversed []ParsedName
+	if err == nil && parsed.TransitionTo == "" {
+		for _, animation := range set.Animations {
+			if animation == nil {
+				continue
+			}
+			other, err := ParseName(animation.Name)
+			if err == nil && other.Bidirectional && other.Action == parsed.Action && other.Char == parsed.Char &&
+				atoi(other.Clip) == atoi(parsed.Clip) {
+				reversed = append(reversed, other)
+			}
+		}
+	}
+
+	var neighbors []*Animation
+	for _, animation := range set.Animations {
+		if animation == nil || animation.Name == name {
+			continue
+		}
+		if animation.references(name) {
+			neighbors = append(neighbors, animation)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		other, err := ParseName(animation.Name)
+		if err != nil {
+			continue
+		}
+		nearby := other.Action == parsed.Action && other.Char == parsed.Char &&
+			abs(atoi(other.Clip)-atoi(parsed.Clip)) <= 1
+		// Transitions lead to it however far their clip is (e.g., 01-05, or X_02-Y_03 into another character)
+		into := other.TransitionTo != "" && endsAt(other, parsed)
+		// A bidirectional transition is played backwards from the clip it ends at (e.g., relax_01 -> intro_01)
+		from := parsed.Bidirectional && endsAt(parsed, other)
+		for _, transition := range reversed {
+			from = from || endsAt(transition, other)
+		}
+		if nearby || into || from || other.NextName == parsed.Action {
+			neighbors = append(neighbors, animation)
+		}
+	}
+	return neighbors
+}
+
+// endsAt reports whether the transition parsed into transition leads to the clip parsed into clip.
+func endsAt(transition, clip ParsedName) bool {
+	return clip.TransitionTo == "" && clip.Action == transitionAction(transition) &&
+		clip.Char == transitionChar(transition) && atoi(clip.Clip) == atoi(transition.NextClip)
+}
+
+// transitionAction returns the action the transition parsed leads to, its own one unless it names another.
+func transitionAction(parsed ParsedName) string {
+	if parsed.NextName != "" {
+		return parsed.NextName
+	}
+	return parsed.Action
+}
+
+// transitionChar returns the character the transition parsed leads to, see transitionTarget.
+func transitionChar(parsed ParsedName) string {
+	if parsed.NextName == "" && parsed.NextChar == "" {
+		return parsed.Char
+	}
+	return parsed.NextChar
+}
+
+// SetPattern makes expression the naming pattern, see usePattern, and resolves the whole set again with it.
+// The groups trusted from a CSV table with -trust-columns were checked against the names with the previous pattern,
+// so they're dropped and the names matched with the new one, the way other clips find them.
+// A lazy set resolves each animation again on its next lookup instead.
+// It fails for sets shaped by passes, see patternPasses, since resolving again would silently undo them.
+// On error the set and the active pattern are left as they were.
+func (set *AnimationSet) SetPattern(expression string) error {
+	if len(set.passes) > 0 {
+		return fmt.Errorf("the pattern can't be changed for animations resolved with %s", strings.Join(set.passes, ", "))
+	}
+	if err := usePattern(expression); err != nil {
+		return err
+	}
+	for _, animation := range set.Animations {
+		if animation != nil {
+			animation.groups = nil
+		}
+	}
+	if set.lazy {
+		set.graph = nil
+		set.resolved = make(map[string]bool)
+		for _, animation := range set.Animations {
+			if animation != nil {
+				animation.reset()
+			}
+		}
+		return nil
+	}
+	var all []*Animation
+	for _, animation := range set.Animations {
+		if animation != nil {
+			all = append(all, animation)
+		}
+	}
+	set.resolve(all)
+	return nil
+}
+
+// resolve recomputes the relations of the given animations against the whole set.
+func (set *AnimationSet) resolve(animations []*Animation) {
+	set.graph = nil
+	for _, animation := range animations {
+		animation.reset()
+		animation.getNextAnimation(set)
+		animation.getAlternateAnimation(set)
+		animation.getRelations(set)
+		if parsed, err := ParseName(animation.Name); err == nil {
+			animation.Tag = parsed.Tag
+		}
+		animation.getPreviousAnimation(set)
+	}
+}
+
+// references reports whether name is one of the clip's next, alternate, previous or custom related animations.
+func (clip *Animation) references(name string) bool {
+	if clip.PreviousAnimation == name || contains(clip.NextAnimations, name) || contains(clip.AlternateAnimations, name) {
+		return true
+	}
+	for _, related := range clip.Relations {
+		if contains(related, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// reset clears everything resolved for the clip.
+func (clip *Animation) reset() {
+	clip.NextAnimations = nil
+	clip.AlternateAnimations = nil
+	clip.PreviousAnimation = ""
+	clip.Tag = ""
+	clip.Relations = nil
+	clip.selfLoop = false
+	clip.reasons = nil
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// incremental are names whose relations reach past the neighboring clip numbers.
+var incremental = []string{
+	"A_intro_01",
+	"A_intro_01-05",
+	"A_intro_02",
+	"A_intro_05",
+	"A_intro_05_B",
+	"A_intro_X_01",
+	"A_intro_X_01-Y_04",
+	"A_intro_Y_04",
+	"A_intro_02-relax_03",
+	"A_relax_03",
+}
+
+// assertSameAsFull fails the test unless set resolved the same as fetchAnimations resolving names from scratch.
+func assertSameAsFull(t *testing.T, set *AnimationSet, names []string, change string) {
+	t.Helper()
+	got, err := json.Marshal(set.Animations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(fetchAnimations(animationsOf(names...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s gave\n%s\nwant\n%s", change, got, want)
+	}
+}
+
+func testIncremental(t *testing.T, names []string) {
+	for i, name := range names {
+		others := append(append([]string(nil), names[:i]...), names[i+1:]...)
+
+		set := NewAnimationSet(animationsOf(others...))
+		if err := set.AddAndResolve(name); err != nil {
+			t.Fatal(err)
+		}
+		assertSameAsFull(t, set, names, "adding "+name)
+
+		set = NewAnimationSet(animationsOf(names...))
+		if err := set.RemoveAndResolve(name); err != nil {
+			t.Fatal(err)
+		}
+		assertSameAsFull(t, set, others, "removing "+name)
+	}
+}
+
+func TestIncrementalResolve(t *testing.T) {
+	testIncremental(t, incremental)
+}
+
+func TestIncrementalResolveBidirectional(t *testing.T) {
+	p := defaultProfile
+	p.BidirectionalSeparator = "<->"
+	withProfile(t, p)
+	testIncremental(t, append([]string{"A_intro_03<->relax_01", "A_relax_01", "A_intro_03"}, incremental...))
+}
+
+func TestLazyAnimationSet(t *testing.T) {
+	names := append([]string{"A_intro_01-05", "A_intro_05", "A_intro_X_01", "A_intro_X_01-Y_04", "A_intro_Y_04"}, sample...)
+	eager := NewAnimationSet(animationsOf(names...))
+	lazy := NewLazyAnimationSet(animationsOf(names...))
+
+	for _, name := range names {
+		if got, want := lazy.Next(name), eager.Next(name); !equalNames(got, want) {
+			t.Errorf("lazy next of %s = %q, want %q", name, got, want)
+		}
+		if got, want := lazy.Alternates(name), eager.Alternates(name); !equalNames(got, want) {
+			t.Errorf("lazy alternates of %s = %q, want %q", name, got, want)
+		}
+		if got, want := lazy.Previous(name), eager.Previous(name); got != want {
+			t.Errorf("lazy previous of %s = %q, want %q", name, got, want)
+		}
+	}
+
+	got, err := json.Marshal(lazy.Animations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(eager.Animations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("resolving lazily gave\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSetPatternTrustedColumns(t *testing.T) {
+	withProfile(t, defaultProfile)
+	groups, err := trustedGroups("A_intro_01", []string{"A_intro_01", "intro", "01"}, map[Group]int{GroupAction: 1, GroupClip: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := NewAnimationSet([]*Animation{{Name: "A_intro_01", groups: groups}, {Name: "A_intro_02"}})
+	if got := set.Next("A_intro_01"); !equalNames(got, []string{"A_intro_02"}) {
+		t.Fatalf("next of A_intro_01 = %q, want A_intro_02", got)
+	}
+
+	// The groups come in another order in this pattern
+	if err := set.SetPattern(`(?P<tag>[a-z]+_)?A_(?P<action>[a-z]+)_(?P<clip>\d{2})`); err != nil {
+		t.Fatal(err)
+	}
+	if got := set.Next("A_intro_01"); !equalNames(got, []string{"A_intro_02"}) {
+		t.Errorf("next of A_intro_01 with the new pattern = %q, want A_intro_02", got)
+	}
+	if set.ByName("A_intro_01").groups != nil {
+		t.Error("the groups checked against the previous pattern were kept")
+	}
+}
+
+func TestSetPatternAfterPasses(t *testing.T) {
+	withProfile(t, defaultProfile)
+	withOpts(t)
+	opts.depth = true
+
+	set := NewAnimationSet(animationsOf("A_intro_01", "A_intro_02"))
+	set.passes = patternPasses()
+	pattern := re
+	if err := set.SetPattern(`A_(?P<action>[a-z]+)_(?P<clip>\d+)`); err == nil {
+		t.Error("changing the pattern of a set resolved with -depth should fail")
+	}
+	if re != pattern {
+		t.Error("the failed pattern change replaced the active pattern")
+	}
+}
+
+func TestREPLPattern(t *testing.T) {
+	withProfile(t, defaultProfile)
+	set := NewAnimationSet(animationsOf("A_intro_01", "A_intro_02"))
+	pattern := re.String()
+
+	var out strings.Builder
+	runREPL(set, strings.NewReader(":patternfoo\n:pattern\n:pattern\tA_(?P<action>[a-z]+)_(?P<clip>\\d+)\n"), &out)
+	lines := strings.Split(out.String(), "\n")
+	if want := "> unknown command :patternfoo, type help for usage"; lines[0] != want {
+		t.Errorf("running :patternfoo printed %q, want %q", lines[0], want)
+	}
+	if want := "> pattern is " + pattern; lines[1] != want {
+		t.Errorf("running :pattern printed %q, want %q", lines[1], want)
+	}
+	if want := "> resolved 2 animations with the new pattern"; lines[2] != want {
+		t.Errorf("running :pattern with an expression printed %q, want %q", lines[2], want)
+	}
+}
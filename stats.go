@@ -0,0 +1,81 @@
+package main
+
+// GraphStats are aggregate metrics of the transition graph, printed by -graph-stats.
+type GraphStats struct {
+	Animations int `json:"animations"`
+	// Components is the number of weakly connected components, following next, alternate and previous relations either way.
+	Components int `json:"components"`
+	// Isolated is the number of clips without any relation.
+	Isolated     int     `json:"isolated"`
+	MaxOutDegree int     `json:"maxOutDegree"`
+	AvgOutDegree float64 `json:"avgOutDegree"`
+	MaxInDegree  int     `json:"maxInDegree"`
+	AvgInDegree  float64 `json:"avgInDegree"`
+	// LongestSequence is the number of clips of the longest Sequence starting at one of the Roots.
+	LongestSequence int `json:"longestSequence"`
+}
+
+// graphStats computes the metrics of the set. Degrees only count next animations,
+// the in-degree of a clip being the number of clips playing into it.
+func graphStats(set *AnimationSet) GraphStats {
+	graph := set.Graph()
+	stats := GraphStats{}
+
+	component := make(map[string]bool)
+	var outDegrees, inDegrees int
+	for _, animation := range set.Animations {
+		if animation == nil {
+			continue
+		}
+		stats.Animations++
+
+		out := len(animation.NextAnimations)
+		in := 0
+		for _, edge := range graph.In(animation.Name) {
+			if edge.Kind == NextEdge {
+				in++
+			}
+		}
+		outDegrees += out
+		inDegrees += in
+		stats.MaxOutDegree = max(stats.MaxOutDegree, out)
+		stats.MaxInDegree = max(stats.MaxInDegree, in)
+
+		if len(graph.Out(animation.Name)) == 0 && len(graph.In(animation.Name)) == 0 {
+			stats.Isolated++
+		}
+
+		if component[animation.Name] {
+			continue
+		}
+		stats.Components++
+		stack := []string{animation.Name}
+		component[animation.Name] = true
+		for len(stack) > 0 {
+			name := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, edge := range append(graph.Out(name), graph.In(name)...) {
+				neighbor := edge.To
+				if neighbor == name {
+					neighbor = edge.From
+				}
+				if component[neighbor] || set.Get(neighbor) == nil {
+					continue
+				}
+				component[neighbor] = true
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+	if stats.Animations > 0 {
+		stats.AvgOutDegree = float64(outDegrees) / float64(stats.Animations)
+		stats.AvgInDegree = float64(inDegrees) / float64(stats.Animations)
+	}
+
+	for _, root := range Roots(set.Animations) {
+		if sequence, err := Sequence(root, set); err == nil {
+			stats.LongestSequence = max(stats.LongestSequence, len(sequence))
+		}
+	}
+	return stats
+}
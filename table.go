@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// writeTable writes the animations as aligned columns for reading in a terminal.
+// Lists longer than -max-list are cut, ending with how many were left out.
+func writeTable(w io.Writer, animations []*Animation) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "NAME\tNEXT\tALTERNATES\tPREVIOUS")
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		previous := animation.PreviousAnimation
+		if previous == "" {
+			previous = "-"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", animation.Name, displayList(animation.NextAnimations), displayList(animation.AlternateAnimations), previous)
+	}
+	return table.Flush()
+}
+
+// displayList joins names for the human-readable formats, keeping at most -max-list of them.
+func displayList(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+	if opts.maxList > 0 && len(names) > opts.maxList {
+		return fmt.Sprintf("%s (+%d more)", strings.Join(names[:opts.maxList], ", "), len(names)-opts.maxList)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// writeTGF writes the transition graph in the Trivial Graph Format: a `id name` line for every clip, a `#` line,
+// and a `source target kind` line for every next and alternate relation.
+// Clips are numbered from 1 in output order, or identified by their ID with -with-ids.
+func writeTGF(w io.Writer, animations []*Animation) error {
+	ids := make(map[string]string, len(animations))
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		id := strconv.Itoa(len(ids) + 1)
+		if opts.withIDs {
+			id = animationID(animation.Name)
+		}
+		ids[animation.Name] = id
+		if _, err := fmt.Fprintf(w, "%s %s\n", id, animation.Name); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, "#"); err != nil {
+		return err
+	}
+	for _, edge := range edges(animations, NextEdge, AlternateEdge) {
+		to, ok := ids[edge.To]
+		if !ok {
+			// TGF edges can only connect declared nodes
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s %s %s\n", ids[edge.From], to, edge.Kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
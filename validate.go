@@ -0,0 +1,354 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Issue is a problem found while validating the resolved animations.
+type Issue struct {
+	Check   string `json:"check"`
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+// Severity is how serious the issues of a check are.
+type Severity string
+
+const (
+	// SeverityError issues break playback, such as transitions leading nowhere.
+	SeverityError Severity = "error"
+	// SeverityWarning issues are suspicious but may be intended, such as clips branching into several next animations.
+	SeverityWarning Severity = "warning"
+)
+
+// severities are the severities of the checks whose issues aren't errors.
+var severities = map[string]Severity{
+	"unparseable":          SeverityWarning,
+	"divergent-alternates": SeverityWarning,
+	"branch":               SeverityWarning,
+}
+
+// severity returns the severity of the issue, an error unless its check is listed in severities.
+func (issue Issue) severity() Severity {
+	if severity, ok := severities[issue.Check]; ok {
+		return severity
+	}
+	return SeverityError
+}
+
+// ValidationReport is the outcome of every check, split by severity, printed by -validate-strict.
+type ValidationReport struct {
+	Errors   []Issue `json:"errors"`
+	Warnings []Issue `json:"warnings"`
+}
+
+// Failed reports whether any error-level issue was found.
+func (report ValidationReport) Failed() bool {
+	return len(report.Errors) > 0
+}
+
+// validateStrict runs the checks of validate, verifyFiles and findBranches over the resolved animations.
+func validateStrict(animations []*Animation, onDisk map[string]bool) ValidationReport {
+	issues := validate(animations)
+	issues = append(issues, verifyFiles(animations, onDisk)...)
+	issues = append(issues, findBranches(animations)...)
+
+	report := ValidationReport{Errors: []Issue{}, Warnings: []Issue{}}
+	for _, issue := range issues {
+		if issue.severity() == SeverityError {
+			report.Errors = append(report.Errors, issue)
+		} else {
+			report.Warnings = append(report.Warnings, issue)
+		}
+	}
+	return report
+}
+
+func (issue Issue) String() string {
+	if issue.Name == "" {
+		return fmt.Sprintf("%s: %s", issue.Check, issue.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", issue.Check, issue.Name, issue.Message)
+}
+
+// validations are the checks run by -validate, in order.
+var validations = []func(animations []*Animation) []Issue{
+	findNoAnimations,
+	findUnparseableNames,
+	findSelfLoops,
+	findDivergentAlternates,
+	findMissingTransitionTargets,
+	findTransitionCycles,
+	findOneWaySequences,
+}
+
+// validate runs every check over the resolved animations and returns the issues found.
+func validate(animations []*Animation) []Issue {
+	var issues []Issue
+	for _, check := range validations {
+		issues = append(issues, check(animations)...)
+	}
+	return issues
+}
+
+// findNoAnimations reports an empty set, which usually means the wrong folder or manifest was given.
+func findNoAnimations(animations []*Animation) []Issue {
+	for _, animation := range animations {
+		if animation != nil {
+			return nil
+		}
+	}
+	return []Issue{{
+		Check:   "empty",
+		Message: ErrNoAnimations.Error(),
+	}}
+}
+
+// findSelfLoops reports the clips that resolved themselves as their next animation.
+func findSelfLoops(animations []*Animation) []Issue {
+	var issues []Issue
+	for _, animation := range animations {
+		if animation == nil || !animation.selfLoop {
+			continue
+		}
+		issues = append(issues, Issue{
+			Check:   "self-loop",
+			Name:    animation.Name,
+			Message: "clip matched itself as its next animation",
+		})
+	}
+	return issues
+}
+
+// findDivergentAlternates reports alternate families whose members advance to different next animations.
+// `A_intro_01_A` and `A_intro_01_B` should both lead to `A_intro_02`, mismatched targets usually mean a naming or export error.
+// Members without next animations are left out, since alternates other than the first one (A) don't resolve any.
+func findDivergentAlternates(animations []*Animation) []Issue {
+	byName := indexByName(animations)
+	seen := make(map[string]bool)
+
+	var issues []Issue
+	for _, animation := range animations {
+		if animation == nil || len(animation.AlternateAnimations) == 0 {
+			continue
+		}
+
+		family := append([]string{animation.Name}, animation.AlternateAnimations...)
+		sort.Strings(family)
+		key := strings.Join(family, ",")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		targets := make(map[string][]string)
+		for _, name := range family {
+			member := byName[name]
+			if member == nil || len(member.NextAnimations) == 0 {
+				continue
+			}
+			next := strings.Join(member.NextAnimations, ", ")
+			targets[next] = append(targets[next], name)
+		}
+		if len(targets) < 2 {
+			continue
+		}
+
+		var conflicts []string
+		for next, members := range targets {
+			conflicts = append(conflicts, fmt.Sprintf("%s -> [%s]", strings.Join(members, ", "), next))
+		}
+		sort.Strings(conflicts)
+		issues = append(issues, Issue{
+			Check:   "divergent-alternates",
+			Name:    family[0],
+			Message: fmt.Sprintf("alternates [%s] advance to different clips: %s", key, strings.Join(conflicts, "; ")),
+		})
+	}
+	return issues
+}
+
+// verifyFiles reports the next and previous animations that don't name one of the files in onDisk.
+func verifyFiles(animations []*Animation, onDisk map[string]bool) []Issue {
+	var issues []Issue
+	missing := func(animation *Animation, relation, target string) {
+		issues = append(issues, Issue{
+			Check:   "missing-file",
+			Name:    animation.Name,
+			Message: fmt.Sprintf("%s animation %s has no file", relation, target),
+		})
+	}
+
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		for _, next := range animation.NextAnimations {
+			if !onDisk[next] {
+				missing(animation, "next", next)
+			}
+		}
+		if previous := animation.PreviousAnimation; previous != "" && !onDisk[previous] {
+			missing(animation, "previous", previous)
+		}
+	}
+	return issues
+}
+
+// findMissingTransitionTargets reports the transition animations that don't lead to any clip.
+func findMissingTransitionTargets(animations []*Animation) []Issue {
+	var issues []Issue
+	for _, animation := range animations {
+		if animation == nil || !animation.isTransition() || len(animation.NextAnimations) > 0 {
+			continue
+		}
+		issues = append(issues, Issue{
+			Check:   "missing-transition-target",
+			Name:    animation.Name,
+			Message: ErrMissingTransitionTarget.Error(),
+		})
+	}
+	return issues
+}
+
+// findTransitionCycles reports the next animations leading from a transition animation back to itself,
+// whether through other transitions only, such as `A_x_01-02` -> `A_x_02-01` -> `A_x_01-02`,
+// or through the content clips in between, such as `A_x_01` -> `A_x_01-02` -> `A_x_02` -> `A_x_02-01` -> `A_x_01`.
+// Each cycle is reported once, on its first member in name order.
+func findTransitionCycles(animations []*Animation) []Issue {
+	byName := indexByName(animations)
+	seen := make(map[string]bool)
+
+	var issues []Issue
+	for _, animation := range animations {
+		if animation == nil || !animation.isTransition() || seen[animation.Name] {
+			continue
+		}
+
+		cycle := transitionCycle(animation, byName)
+		if cycle == nil {
+			continue
+		}
+		for _, name := range cycle {
+			seen[name] = true
+		}
+		members := append([]string(nil), cycle...)
+		sort.Strings(members)
+		issues = append(issues, Issue{
+			Check:   "transition-cycle",
+			Name:    members[0],
+			Message: fmt.Sprintf("%s: %s", ErrTransitionCycle, strings.Join(append(cycle, cycle[0]), " -> ")),
+		})
+	}
+	return issues
+}
+
+// transitionCycle follows the next animations starting at clip and returns the first path leading back to it, if any.
+// Every animation is followed once, so other cycles along the way don't loop forever.
+func transitionCycle(clip *Animation, byName map[string]*Animation) []string {
+	visited := map[string]bool{clip.Name: true}
+	var follow func(current *Animation, path []string) []string
+	follow = func(current *Animation, path []string) []string {
+		path = append(path, current.Name)
+		for _, next := range current.NextAnimations {
+			if next == clip.Name {
+				return path
+			}
+			target := byName[next]
+			if target == nil || visited[next] {
+				continue
+			}
+			visited[next] = true
+			if cycle := follow(target, path); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return follow(clip, nil)
+}
+
+// findOneWaySequences reports the sequential next animations that don't point back at the clip as their previous animation,
+// such as `A_intro_01` -> `A_intro_02` while `A_intro_02` has no previous animation, revealing a bug in either resolver.
+// The previous animation may also be another clip of the same number, such as an alternate or a tagged clip,
+// since all of them advance to the same next clip.
+func findOneWaySequences(animations []*Animation) []Issue {
+	byName := indexByName(animations)
+
+	var issues []Issue
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		for _, edge := range animation.successors() {
+			next := byName[edge.Target]
+			if edge.Reason != ReasonSequential || next == nil {
+				continue
+			}
+			previous := next.PreviousAnimation
+			if previous == animation.Name || sameClipNumber(previous, animation.Name) {
+				continue
+			}
+			if previous == "" {
+				previous = "none"
+			}
+			issues = append(issues, Issue{
+				Check:   "one-way-sequence",
+				Name:    animation.Name,
+				Message: fmt.Sprintf("next animation %s has %s as its previous animation", edge.Target, previous),
+			})
+		}
+	}
+	return issues
+}
+
+// sameClipNumber reports whether both names parse to the same action, character and clip number.
+func sameClipNumber(a, b string) bool {
+	parsedA, errA := ParseName(a)
+	parsedB, errB := ParseName(b)
+	return errA == nil && errB == nil &&
+		parsedA.Action == parsedB.Action && parsedA.Char == parsedB.Char && parsedA.Clip == parsedB.Clip
+}
+
+// findUnparseableNames reports the names that don't match the naming pattern, such as the action-less `A_01`,
+// and the ones a permissive custom pattern matches with an empty action or clip number.
+// These are kept in the output, but never get any relations.
+func findUnparseableNames(animations []*Animation) []Issue {
+	var issues []Issue
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		if _, err := ParseName(animation.Name); err != nil {
+			message := ErrUnparseableName.Error()
+			if nameErr := (*NameError)(nil); errors.As(err, &nameErr) {
+				message = nameErr.Err.Error()
+			}
+			issues = append(issues, Issue{
+				Check:   "unparseable",
+				Name:    animation.Name,
+				Message: message,
+			})
+		}
+	}
+	return issues
+}
+
+// findBranches reports the clips with more than one next animation, for projects requiring strictly linear sequences.
+func findBranches(animations []*Animation) []Issue {
+	var issues []Issue
+	for _, animation := range animations {
+		if animation == nil || len(animation.NextAnimations) < 2 {
+			continue
+		}
+		issues = append(issues, Issue{
+			Check:   "branch",
+			Name:    animation.Name,
+			Message: fmt.Sprintf("clip has %d next animations: %s", len(animation.NextAnimations), strings.Join(animation.NextAnimations, ", ")),
+		})
+	}
+	return issues
+}
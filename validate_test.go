@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindTransitionCycles(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{
+			[]string{"A_x_01", "A_x_01-02", "A_x_02", "A_x_02-01"},
+			"A_x_01-02 -> A_x_02 -> A_x_02-01 -> A_x_01 -> A_x_01-02",
+		},
+		{
+			[]string{"A_x_01", "A_x_01-02", "A_x_02", "A_x_02-relax_01", "A_relax_01", "A_relax_01-x_01"},
+			"A_relax_01-x_01 -> A_x_01 -> A_x_01-02 -> A_x_02 -> A_x_02-relax_01 -> A_relax_01 -> A_relax_01-x_01",
+		},
+		{
+			[]string{"A_x_01", "A_x_01-02", "A_x_02", "A_x_03"},
+			"",
+		},
+	}
+	for _, test := range tests {
+		issues := findTransitionCycles(fetchAnimations(animationsOf(test.names...)))
+		if test.want == "" {
+			if len(issues) != 0 {
+				t.Errorf("%q reported %v, want no cycle", test.names, issues)
+			}
+			continue
+		}
+		if len(issues) != 1 || !strings.HasSuffix(issues[0].Message, test.want) {
+			t.Errorf("%q reported %v, want the cycle %s", test.names, issues, test.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// weightsSuffix ends the name of the sidecar files holding the selection weights of an alternate pool,
+// such as `A_idle_01.weights.json` next to `A_idle_01.anim`.
+const weightsSuffix = ".weights.json"
+
+// defaultWeight is the weight of the alternates that no sidecar lists.
+const defaultWeight = 1.0
+
+// WeightedAlternate is an alternate animation along with its selection weight, output with -weights.
+type WeightedAlternate struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+// readWeights reads the sidecar file at path, a JSON object mapping the animations of a pool to their weights:
+//
+//	{"A_idle_01": 3, "A_idle_01_B": 1, "A_idle_01_C": 0.5}
+func readWeights(path string) (map[string]float64, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var weights map[string]float64
+	if err := json.Unmarshal(bytes, &weights); err != nil {
+		return nil, fmt.Errorf("reading weights from %s: %w", path, err)
+	}
+	for name, weight := range weights {
+		if weight < 0 {
+			return nil, fmt.Errorf("reading weights from %s: %s has a negative weight", path, name)
+		}
+	}
+	return weights, nil
+}
+
+// isWeightsFile reports whether the file called filename is a weights sidecar rather than an animation.
+func isWeightsFile(filename string) bool {
+	return strings.HasSuffix(filename, weightsSuffix)
+}
+
+// assignWeights sets the weight of every animation listed in weights.
+func assignWeights(animations []*Animation, weights map[string]float64) {
+	for _, animation := range animations {
+		if weight, ok := weights[animation.Name]; ok {
+			animation.weight = weight
+			animation.weighted = true
+		}
+	}
+}
+
+// weightedAlternates returns the alternates of the clip with the weights of the animations in byName.
+func (clip *Animation) weightedAlternates(byName map[string]*Animation) []WeightedAlternate {
+	if clip.AlternateAnimations == nil {
+		return nil
+	}
+	alternates := make([]WeightedAlternate, 0, len(clip.AlternateAnimations))
+	for _, name := range clip.AlternateAnimations {
+		weight := defaultWeight
+		if alternate := byName[name]; alternate != nil && alternate.weighted {
+			weight = alternate.weight
+		}
+		alternates = append(alternates, WeightedAlternate{Name: name, Weight: weight})
+	}
+	return alternates
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FilterByFields returns the animations whose parsed name satisfies pred, leaving out the names that don't parse.
+// Unlike filterAnimations it matches the decoded parts, so `Action == "intro"` doesn't also match `A_introduction_01`.
+func FilterByFields(pred func(ParsedName) bool, animations []*Animation) []*Animation {
+	var filtered []*Animation
+	for _, animation := range animations {
+		if animation == nil {
+			continue
+		}
+		parsed, err := ParseName(animation.Name)
+		if err == nil && pred(parsed) {
+			filtered = append(filtered, animation)
+		}
+	}
+	return filtered
+}
+
+// field returns the part of the name captured by group.
+func (parsed ParsedName) field(group Group) string {
+	switch group {
+	case GroupID:
+		return parsed.ID
+	case GroupAction:
+		return parsed.Action
+	case GroupChar:
+		return parsed.Char
+	case GroupClip:
+		return parsed.Clip
+	case GroupAlternate:
+		return parsed.Alternate
+	case GroupTag:
+		return parsed.Tag
+	case GroupTransitionTo:
+		return parsed.TransitionTo
+	case GroupNextName:
+		return parsed.NextName
+	case GroupNextChar:
+		return parsed.NextChar
+	case GroupNextClip:
+		return parsed.NextClip
+	case GroupBidirectional:
+		if parsed.Bidirectional {
+			return profile.BidirectionalSeparator
+		}
+	}
+	return ""
+}
+
+// parseWhere compiles a -where expression of comma separated `group=value` conditions that must all hold,
+// such as `action=intro,char=X,alternate=*`. The value `*` requires the part to be present and an empty value
+// requires it to be absent. The groups are the ones named on re.
+func parseWhere(expression string) (func(ParsedName) bool, error) {
+	type condition struct {
+		group Group
+		value string
+	}
+
+	var conditions []condition
+	for _, term := range strings.Split(expression, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(term), "=")
+		if !ok {
+			return nil, fmt.Errorf("condition %q should be group=value", term)
+		}
+		group, ok := groupNamed(key)
+		if !ok || groupIndex[group] < 0 {
+			return nil, fmt.Errorf("unknown group %q in condition %q", key, term)
+		}
+		conditions = append(conditions, condition{group: group, value: value})
+	}
+
+	return func(parsed ParsedName) bool {
+		for _, c := range conditions {
+			actual := parsed.field(c.group)
+			if c.value == "*" && actual == "" || c.value != "*" && actual != c.value {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
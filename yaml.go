@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// yamlPlain matches the scalars written without quotes in the YAML output.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_+-]*$`)
+
+// writeYAMLAnchors writes the animations as a YAML sequence with the same fields as the JSON output.
+// Next and alternate animations are always lists of names, so parseFlags rejects -reasons, -kinded-next and -weights.
+// A list of next or alternate animations appearing more than once is written in full the first time, with an anchor
+// such as `&list1`, and as an `*list1` alias after that, so the sequence shared by a whole family is only listed once.
+func writeYAMLAnchors(w io.Writer, animations []*Animation) error {
+	var present []*Animation
+	for _, animation := range animations {
+		if animation != nil {
+			present = append(present, animation)
+		}
+	}
+	if len(present) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, animation := range present {
+		for _, list := range [][]string{animation.NextAnimations, animation.AlternateAnimations} {
+			if len(list) > 0 {
+				counts[yamlListKey(list)]++
+			}
+		}
+	}
+	anchors := make(map[string]string)
+
+	var out strings.Builder
+	writeList := func(field string, list []string) {
+		if len(list) == 0 {
+			fmt.Fprintf(&out, "  %s: []\n", field)
+			return
+		}
+		key := yamlListKey(list)
+		if anchor, ok := anchors[key]; ok {
+			fmt.Fprintf(&out, "  %s: *%s\n", field, anchor)
+			return
+		}
+		fmt.Fprintf(&out, "  %s:", field)
+		if counts[key] > 1 {
+			anchor := fmt.Sprintf("list%d", len(anchors)+1)
+			anchors[key] = anchor
+			fmt.Fprintf(&out, " &%s", anchor)
+		}
+		out.WriteString("\n")
+		for _, name := range list {
+			fmt.Fprintf(&out, "    - %s\n", yamlScalar(name))
+		}
+	}
+
+	for _, animation := range present {
+		if animation.ID != "" {
+			fmt.Fprintf(&out, "- ID: %s\n  Name: %s\n", yamlScalar(animation.ID), yamlScalar(animation.Name))
+		} else {
+			fmt.Fprintf(&out, "- Name: %s\n", yamlScalar(animation.Name))
+		}
+		writeList("NextAnimations", animation.NextAnimations)
+		writeList("AlternateAnimations", animation.AlternateAnimations)
+		fmt.Fprintf(&out, "  PreviousAnimation: %s\n", yamlScalar(animation.PreviousAnimation))
+		if animation.Tag != "" {
+			fmt.Fprintf(&out, "  Tag: %s\n", yamlScalar(animation.Tag))
+		}
+		if animation.RandomNext {
+			out.WriteString("  RandomNext: true\n")
+		}
+		if animation.ClipIndex != nil {
+			fmt.Fprintf(&out, "  ClipIndex: %d\n", *animation.ClipIndex)
+		}
+		if animation.Depth != nil {
+			fmt.Fprintf(&out, "  Depth: %d\n", *animation.Depth)
+		}
+		if len(animation.Members) > 0 {
+			writeList("Members", animation.Members)
+		}
+		if animation.Path != "" {
+			fmt.Fprintf(&out, "  Path: %s\n", yamlScalar(animation.Path))
+		}
+		if animation.Unparsed {
+			out.WriteString("  Unparsed: true\n")
+		}
+		if len(animation.Relations) > 0 {
+			out.WriteString("  Relations:\n")
+			kinds := make([]string, 0, len(animation.Relations))
+			for kind := range animation.Relations {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+			for _, kind := range kinds {
+				fmt.Fprintf(&out, "    %s:", yamlScalar(kind))
+				if len(animation.Relations[kind]) == 0 {
+					out.WriteString(" []\n")
+					continue
+				}
+				out.WriteString("\n")
+				for _, name := range animation.Relations[kind] {
+					fmt.Fprintf(&out, "      - %s\n", yamlScalar(name))
+				}
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// yamlListKey identifies the content of a list of names.
+func yamlListKey(list []string) string {
+	return strings.Join(list, "\x00")
+}
+
+// yamlScalar returns s as a YAML scalar, double quoted unless it's a plain name.
+// A JSON string is a valid double-quoted YAML scalar.
+func yamlScalar(s string) string {
+	if yamlPlain.MatchString(s) && s != "true" && s != "false" && s != "null" {
+		return s
+	}
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}